@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// Sources say where to find an access token when access_token isn't set.
+type Sources struct {
+	// Command prints the access token, e.g. `pass show gitlab`. See access_token_command.
+	Command string
+	// Account is the keyring entry to look in, i.e. the profile's name.
+	Account string
+}
+
+var (
+	commandTokens   = map[string]string{}
+	commandTokensMu sync.Mutex
+)
+
+// AccessToken returns token if it's set. Otherwise it runs the access token command, or looks in
+// the keyring if there isn't one. It returns an empty token if there's no keyring or nothing in it.
+func AccessToken(token string, sources Sources) (string, error) {
+	if token != "" {
+		return token, nil
+	}
+
+	if sources.Command != "" {
+		return RunCommand(sources.Command)
+	}
+
+	keyring, err := newKeyring()
+	if errors.Is(err, ErrNoKeyring) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	token, err = keyring.Get(sources.Account)
+	if errors.Is(err, ErrNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("couldn't read the access token for %s from the keyring: %w", sources.Account, err)
+	}
+	return token, nil
+}
+
+// RunCommand runs an access token command with sh and returns the first line it prints. Each
+// command runs once per process; later calls return its token again.
+func RunCommand(command string) (string, error) {
+	commandTokensMu.Lock()
+	defer commandTokensMu.Unlock()
+
+	if token, ok := commandTokens[command]; ok {
+		return token, nil
+	}
+
+	var stderr bytes.Buffer
+	tokenCmd := exec.Command("sh", "-c", command)
+	tokenCmd.Stderr = &stderr
+	output, err := tokenCmd.Output()
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("couldn't run access_token_command %q: %w: %s", command, err, message)
+		}
+		return "", fmt.Errorf("couldn't run access_token_command %q: %w", command, err)
+	}
+
+	token := strings.TrimSpace(strings.SplitN(string(output), "\n", 2)[0])
+	if token == "" {
+		return "", fmt.Errorf("access_token_command %q didn't print a token", command)
+	}
+
+	commandTokens[command] = token
+	return token, nil
+}
+
+// Store saves the access token for account, i.e. a profile, in the keyring.
+func Store(account string, token string) error {
+	keyring, err := newKeyring()
+	if err != nil {
+		return err
+	}
+	if err := keyring.Set(account, token); err != nil {
+		return fmt.Errorf("couldn't store the access token for %s in the keyring: %w", account, err)
+	}
+	return nil
+}
+
+// Forget removes the access token for account from the keyring.
+func Forget(account string) error {
+	keyring, err := newKeyring()
+	if err != nil {
+		return err
+	}
+	if err := keyring.Delete(account); err != nil {
+		return fmt.Errorf("couldn't remove the access token for %s from the keyring: %w", account, err)
+	}
+	return nil
+}
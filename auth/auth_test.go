@@ -0,0 +1,131 @@
+package auth
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeKeyring keeps tokens in memory.
+type fakeKeyring map[string]string
+
+func (keyring fakeKeyring) Get(account string) (string, error) {
+	token, ok := keyring[account]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return token, nil
+}
+
+func (keyring fakeKeyring) Set(account string, token string) error {
+	keyring[account] = token
+	return nil
+}
+
+func (keyring fakeKeyring) Delete(account string) error {
+	if _, ok := keyring[account]; !ok {
+		return ErrNotFound
+	}
+	delete(keyring, account)
+	return nil
+}
+
+func useKeyring(t *testing.T, keyring Keyring, err error) {
+	t.Helper()
+	original := newKeyring
+	newKeyring = func() (Keyring, error) { return keyring, err }
+	t.Cleanup(func() { newKeyring = original })
+}
+
+func TestAccessToken(t *testing.T) {
+	keyring := fakeKeyring{"oss": "keyring-token"}
+	useKeyring(t, keyring, nil)
+
+	tests := []struct {
+		name    string
+		token   string
+		sources Sources
+		want    string
+	}{
+		{name: "token wins", token: "plain-token", sources: Sources{Command: "echo command-token", Account: "oss"}, want: "plain-token"},
+		{name: "command before keyring", sources: Sources{Command: "echo command-token", Account: "oss"}, want: "command-token"},
+		{name: "keyring", sources: Sources{Account: "oss"}, want: "keyring-token"},
+		{name: "nothing in the keyring", sources: Sources{Account: "default"}, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := AccessToken(tt.token, tt.sources)
+			if err != nil {
+				t.Fatalf("AccessToken() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("AccessToken() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("no keyring", func(t *testing.T) {
+		useKeyring(t, nil, ErrNoKeyring)
+		got, err := AccessToken("", Sources{Account: "oss"})
+		if err != nil || got != "" {
+			t.Errorf("AccessToken() = %q, %v, want no token and no error", got, err)
+		}
+	})
+}
+
+func TestRunCommand(t *testing.T) {
+	runs := filepath.Join(t.TempDir(), "runs")
+	command := "echo run >> " + runs + "; printf ' secret-token \\nuser: me\\n'"
+
+	for i := 0; i < 2; i++ {
+		token, err := RunCommand(command)
+		if err != nil {
+			t.Fatalf("RunCommand() error = %v", err)
+		}
+		if token != "secret-token" {
+			t.Errorf("RunCommand() = %q, want the first line trimmed", token)
+		}
+	}
+
+	data, err := os.ReadFile(runs)
+	if err != nil {
+		t.Fatalf("couldn't read runs: %v", err)
+	}
+	if count := strings.Count(string(data), "run"); count != 1 {
+		t.Errorf("command ran %d times, want once per process", count)
+	}
+
+	if _, err := RunCommand("echo locked >&2; exit 2"); err == nil || !strings.Contains(err.Error(), "locked") {
+		t.Errorf("RunCommand() error = %v, want it to include stderr", err)
+	}
+	if _, err := RunCommand("true"); err == nil {
+		t.Errorf("RunCommand() error = nil, want an error for no output")
+	}
+}
+
+func TestStoreAndForget(t *testing.T) {
+	keyring := fakeKeyring{}
+	useKeyring(t, keyring, nil)
+
+	if err := Store("oss", "new-token"); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+	if keyring["oss"] != "new-token" {
+		t.Errorf("keyring = %v, want the token stored for oss", keyring)
+	}
+
+	if err := Forget("oss"); err != nil {
+		t.Fatalf("Forget() error = %v", err)
+	}
+	if err := Forget("oss"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Forget() error = %v, want ErrNotFound", err)
+	}
+
+	useKeyring(t, nil, ErrNoKeyring)
+	if err := Store("oss", "new-token"); !errors.Is(err, ErrNoKeyring) {
+		t.Errorf("Store() error = %v, want ErrNoKeyring", err)
+	}
+}
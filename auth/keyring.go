@@ -0,0 +1,139 @@
+package auth
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// service names macglab's entries in the keyring. Each profile has its own account.
+const service = "macglab"
+
+var (
+	// ErrNoKeyring means there's no keyring command to store access tokens with.
+	ErrNoKeyring = errors.New("couldn't find a keyring: install secret-tool (libsecret) or use access_token_command instead")
+	// ErrNotFound means the keyring has no access token for an account.
+	ErrNotFound = errors.New("no access token in the keyring")
+)
+
+// Keyring stores access tokens in the operating system's secret store.
+type Keyring interface {
+	Get(account string) (string, error)
+	Set(account string, token string) error
+	Delete(account string) error
+}
+
+// newKeyring finds the keyring. Tests replace it.
+var newKeyring = DefaultKeyring
+
+// execCommand runs the keyring commands. Tests replace it.
+var execCommand = exec.Command
+
+// DefaultKeyring returns the macOS keychain, or the Secret Service (e.g. GNOME Keyring or KWallet)
+// via secret-tool elsewhere.
+func DefaultKeyring() (Keyring, error) {
+	if runtime.GOOS == "darwin" {
+		if _, err := exec.LookPath("security"); err == nil {
+			return keychain{}, nil
+		}
+		return nil, ErrNoKeyring
+	}
+	if _, err := exec.LookPath("secret-tool"); err == nil {
+		return secretTool{}, nil
+	}
+	return nil, ErrNoKeyring
+}
+
+// secretTool stores access tokens in the Secret Service with libsecret's secret-tool.
+type secretTool struct{}
+
+func (secretTool) Get(account string) (string, error) {
+	var stderr bytes.Buffer
+	lookupCmd := execCommand("secret-tool", "lookup", "service", service, "account", account)
+	lookupCmd.Stderr = &stderr
+	output, err := lookupCmd.Output()
+	if err != nil {
+		// secret-tool exits 1 without printing anything when there's no match.
+		if stderr.Len() == 0 && len(output) == 0 {
+			return "", ErrNotFound
+		}
+		return "", commandError(stderr.Bytes(), err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+func (secretTool) Set(account string, token string) error {
+	storeCmd := execCommand("secret-tool", "store", "--label=macglab access token ("+account+")", "service", service, "account", account)
+	// Pass the token on stdin so it doesn't show up in the process list.
+	storeCmd.Stdin = strings.NewReader(token)
+	return commandError(storeCmd.CombinedOutput())
+}
+
+func (secretTool) Delete(account string) error {
+	return commandError(execCommand("secret-tool", "clear", "service", service, "account", account).CombinedOutput())
+}
+
+// keychain stores access tokens in the macOS login keychain with security.
+type keychain struct{}
+
+// keychainItemNotFound is the exit code security uses when there's no matching item.
+const keychainItemNotFound = 44
+
+func (keychain) Get(account string) (string, error) {
+	output, err := execCommand("security", "find-generic-password", "-s", service, "-a", account, "-w").Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == keychainItemNotFound {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+func (keychain) Set(account string, token string) error {
+	// security only takes the password as an argument, which every local user can read in the process
+	// list, so run it interactively and send the command, token included, on stdin instead.
+	command := fmt.Sprintf("add-generic-password -U -s %s -a %s -l %s -w %s\n",
+		securityQuote(service), securityQuote(account), securityQuote("macglab access token ("+account+")"), securityQuote(token))
+	setCmd := execCommand("security", "-i")
+	setCmd.Stdin = strings.NewReader(command)
+	output, err := setCmd.CombinedOutput()
+	if err != nil {
+		return commandError(output, err)
+	}
+
+	// Interactive mode exits successfully even when a command fails, so check the token was stored.
+	if stored, err := (keychain{}).Get(account); err != nil || stored != token {
+		return commandError(output, errors.New("security didn't store the access token"))
+	}
+	return nil
+}
+
+// securityQuote quotes an argument for security's interactive mode.
+func securityQuote(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
+
+func (keychain) Delete(account string) error {
+	err := execCommand("security", "delete-generic-password", "-s", service, "-a", account).Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == keychainItemNotFound {
+		return ErrNotFound
+	}
+	return err
+}
+
+// commandError adds what a failed command printed to its error.
+func commandError(output []byte, err error) error {
+	if err == nil {
+		return nil
+	}
+	if message := strings.TrimSpace(string(output)); message != "" {
+		return fmt.Errorf("%w: %s", err, message)
+	}
+	return err
+}
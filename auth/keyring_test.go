@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// keyringCall is a command a keyring ran, with what it sent on stdin.
+type keyringCall struct {
+	args      []string
+	stdinFile string
+}
+
+// recordKeyringCommands replaces the keyring's commands with ones that save their stdin and print output.
+func recordKeyringCommands(t *testing.T, output string) *[]keyringCall {
+	t.Helper()
+	calls := &[]keyringCall{}
+	dir := t.TempDir()
+
+	original := execCommand
+	execCommand = func(name string, args ...string) *exec.Cmd {
+		stdinFile := filepath.Join(dir, fmt.Sprintf("stdin-%d", len(*calls)))
+		*calls = append(*calls, keyringCall{args: append([]string{name}, args...), stdinFile: stdinFile})
+		return exec.Command("sh", "-c", `cat > "$0"; printf %s "$1"`, stdinFile, output)
+	}
+	t.Cleanup(func() { execCommand = original })
+
+	return calls
+}
+
+func TestKeyringSetKeepsTokenOutOfArguments(t *testing.T) {
+	const token = "glpat-very-secret"
+
+	tests := []struct {
+		name    string
+		keyring Keyring
+	}{
+		{name: "keychain", keyring: keychain{}},
+		{name: "secret-tool", keyring: secretTool{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := recordKeyringCommands(t, token)
+
+			if err := tt.keyring.Set("oss", token); err != nil {
+				t.Fatalf("Set() error = %v", err)
+			}
+			if len(*calls) == 0 {
+				t.Fatalf("Set() ran no commands")
+			}
+
+			stdin, err := os.ReadFile((*calls)[0].stdinFile)
+			if err != nil {
+				t.Fatalf("couldn't read stdin: %v", err)
+			}
+			if !strings.Contains(string(stdin), token) {
+				t.Errorf("stdin = %q, want it to carry the token", stdin)
+			}
+			for _, call := range *calls {
+				for _, arg := range call.args {
+					if strings.Contains(arg, token) {
+						t.Errorf("command %q has the token in its arguments", call.args)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestKeychainSetChecksTheTokenWasStored(t *testing.T) {
+	recordKeyringCommands(t, "")
+
+	if err := (keychain{}).Set("oss", "glpat-very-secret"); err == nil {
+		t.Errorf("Set() error = nil, want an error when security doesn't store the token")
+	}
+}
+
+func TestSecurityQuote(t *testing.T) {
+	if got, want := securityQuote(`a "b" \c`), `"a \"b\" \\c"`; got != want {
+		t.Errorf("securityQuote() = %s, want %s", got, want)
+	}
+}
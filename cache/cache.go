@@ -0,0 +1,193 @@
+// Package cache stores GitLab API responses on disk so repeated runs don't re-download everything.
+//
+// Responses are keyed on the request URL and a hash of the access token, so switching
+// tokens never serves another identity's results. Fresh responses are served without
+// touching the network; stale ones are revalidated with If-None-Match when GitLab sent an ETag.
+package cache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultTTL is how long a response is served without asking GitLab.
+const DefaultTTL = 5 * time.Minute
+
+// tokenHeader is the header go-gitlab sends personal access tokens in.
+const tokenHeader = "PRIVATE-TOKEN"
+
+// now is swapped out in tests.
+var now = time.Now
+
+// entry is a cached response as stored on disk.
+type entry struct {
+	Url        string      `json:"url"`
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+	StoredAt   time.Time   `json:"stored_at"`
+}
+
+// Transport is an http.RoundTripper that caches successful GET responses in Dir, and clears them
+// after any other request succeeds.
+type Transport struct {
+	// Base makes the actual requests. Defaults to http.DefaultTransport.
+	Base http.RoundTripper
+	// Dir is where responses are stored.
+	Dir string
+	// TTL is how long a response is served without revalidating it.
+	TTL time.Duration
+	// Refresh ignores fresh responses, but still revalidates and stores them.
+	Refresh bool
+}
+
+// RoundTrip implements http.RoundTripper.
+func (transport *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		res, err := transport.base().RoundTrip(req)
+		// A change like an approval can alter any cached query, e.g. approved_by_ids, so forget them
+		// all. Like writes, failing to clear shouldn't fail the request.
+		if err == nil && res.StatusCode >= 200 && res.StatusCode < 300 {
+			Clear(transport.Dir)
+		}
+		return res, err
+	}
+
+	key := Key(req)
+	cached, _ := transport.read(key)
+
+	if cached != nil && !transport.Refresh && now().Sub(cached.StoredAt) < transport.TTL {
+		return cached.response(req), nil
+	}
+
+	if cached != nil {
+		if etag := cached.Header.Get("ETag"); etag != "" {
+			req = req.Clone(req.Context())
+			req.Header.Set("If-None-Match", etag)
+		}
+	}
+
+	res, err := transport.base().RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode == http.StatusNotModified && cached != nil {
+		res.Body.Close()
+		cached.StoredAt = now()
+		transport.write(key, cached)
+		return cached.response(req), nil
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return res, nil
+	}
+
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(body))
+
+	transport.write(key, &entry{
+		Url:        req.URL.String(),
+		StatusCode: res.StatusCode,
+		Header:     res.Header,
+		Body:       body,
+		StoredAt:   now(),
+	})
+
+	return res, nil
+}
+
+// Key identifies a request by its URL and a hash of its access token.
+func Key(req *http.Request) string {
+	tokenHash := sha256.Sum256([]byte(req.Header.Get(tokenHeader)))
+	keyHash := sha256.Sum256([]byte(req.URL.String() + "\n" + hex.EncodeToString(tokenHash[:])))
+	return hex.EncodeToString(keyHash[:])
+}
+
+// Clear deletes every cached response in dir.
+func Clear(dir string) error {
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("couldn't clear %s: %w", dir, err)
+	}
+	return nil
+}
+
+func (transport *Transport) base() http.RoundTripper {
+	if transport.Base != nil {
+		return transport.Base
+	}
+	return http.DefaultTransport
+}
+
+func (transport *Transport) entryUrl(key string) string {
+	return filepath.Join(transport.Dir, key+".json")
+}
+
+func (transport *Transport) read(key string) (*entry, error) {
+	data, err := os.ReadFile(transport.entryUrl(key))
+	if err != nil {
+		return nil, err
+	}
+
+	var cached entry
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return nil, err
+	}
+
+	return &cached, nil
+}
+
+// write stores an entry. Failing to cache shouldn't fail the request, so errors are ignored.
+func (transport *Transport) write(key string, cached *entry) {
+	data, err := json.Marshal(cached)
+	if err != nil {
+		return
+	}
+
+	if err := os.MkdirAll(transport.Dir, 0700); err != nil {
+		return
+	}
+
+	// Write to a temp file and rename it so concurrent readers never see half an entry.
+	tempFile, err := os.CreateTemp(transport.Dir, key+".*.tmp")
+	if err != nil {
+		return
+	}
+	defer os.Remove(tempFile.Name())
+
+	if _, err := tempFile.Write(data); err != nil {
+		tempFile.Close()
+		return
+	}
+	if err := tempFile.Close(); err != nil {
+		return
+	}
+
+	os.Rename(tempFile.Name(), transport.entryUrl(key))
+}
+
+func (cached *entry) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", cached.StatusCode, http.StatusText(cached.StatusCode)),
+		StatusCode:    cached.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        cached.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(cached.Body)),
+		ContentLength: int64(len(cached.Body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,152 @@
+package cache
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newServer serves a body that changes on every 200, with an ETag GitLab-style.
+func newServer(t *testing.T) (*httptest.Server, *int32) {
+	t.Helper()
+
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hit := atomic.AddInt32(&hits, 1)
+		if r.Header.Get("If-None-Match") == `W/"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `W/"v1"`)
+		w.Header().Set("X-Next-Page", "2")
+		fmt.Fprintf(w, "response %d", hit)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &hits
+}
+
+func get(t *testing.T, client *http.Client, url string, token string) (string, http.Header) {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set(tokenHeader, token)
+
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(body), res.Header
+}
+
+func TestTransport(t *testing.T) {
+	start := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+	clock := start
+	now = func() time.Time { return clock }
+	t.Cleanup(func() { now = time.Now })
+
+	server, hits := newServer(t)
+	dir := t.TempDir()
+	transport := &Transport{Dir: dir, TTL: time.Minute}
+	client := &http.Client{Transport: transport}
+
+	body, header := get(t, client, server.URL+"/mrs", "token-a")
+	if body != "response 1" || header.Get("X-Next-Page") != "2" || *hits != 1 {
+		t.Fatalf("first request: body %q, hits %d", body, *hits)
+	}
+
+	// Fresh responses don't touch the network.
+	body, header = get(t, client, server.URL+"/mrs", "token-a")
+	if body != "response 1" || header.Get("X-Next-Page") != "2" || *hits != 1 {
+		t.Fatalf("fresh request: body %q, hits %d", body, *hits)
+	}
+
+	// Another token never sees the first token's responses.
+	if body, _ = get(t, client, server.URL+"/mrs", "token-b"); body != "response 2" || *hits != 2 {
+		t.Fatalf("other token: body %q, hits %d", body, *hits)
+	}
+
+	// Stale responses are revalidated and served from the cache on 304.
+	clock = start.Add(2 * time.Minute)
+	if body, _ = get(t, client, server.URL+"/mrs", "token-a"); body != "response 1" || *hits != 3 {
+		t.Fatalf("stale request: body %q, hits %d", body, *hits)
+	}
+
+	// Revalidating makes the response fresh again.
+	if body, _ = get(t, client, server.URL+"/mrs", "token-a"); body != "response 1" || *hits != 3 {
+		t.Fatalf("revalidated request: body %q, hits %d", body, *hits)
+	}
+
+	// Refresh always asks GitLab.
+	transport.Refresh = true
+	if body, _ = get(t, client, server.URL+"/mrs", "token-a"); body != "response 1" || *hits != 4 {
+		t.Fatalf("refreshed request: body %q, hits %d", body, *hits)
+	}
+
+	entries, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("got %d cache entries, want 2", len(entries))
+	}
+
+	if err := Clear(dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("expected %s to be removed, got %v", dir, err)
+	}
+}
+
+func TestTransportSkipsWrites(t *testing.T) {
+	server, hits := newServer(t)
+	client := &http.Client{Transport: &Transport{Dir: t.TempDir(), TTL: time.Minute}}
+
+	for i := 0; i < 2; i++ {
+		res, err := client.Post(server.URL+"/approve", "application/json", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+	}
+
+	if *hits != 2 {
+		t.Errorf("got %d hits, want 2", *hits)
+	}
+}
+
+func TestTransportClearsAfterChanges(t *testing.T) {
+	server, hits := newServer(t)
+	client := &http.Client{Transport: &Transport{Dir: t.TempDir(), TTL: time.Minute}}
+
+	get(t, client, server.URL+"/merge_requests", "token")
+	res, err := client.Post(server.URL+"/approve", "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if body, _ := get(t, client, server.URL+"/merge_requests", "token"); body != "response 3" {
+		t.Errorf("got %q after a change, want a fresh response", body)
+	}
+	if *hits != 3 {
+		t.Errorf("got %d hits, want 3", *hits)
+	}
+}
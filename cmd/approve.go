@@ -0,0 +1,227 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/mjburtenshaw/macglab/flags"
+	"github.com/mjburtenshaw/macglab/mrs"
+	"github.com/mjburtenshaw/macglab/utils"
+	"github.com/spf13/cobra"
+	"github.com/xanzy/go-gitlab"
+)
+
+// approvalOptions are the flags approve and unapprove share.
+type approvalOptions struct {
+	comment     string
+	sha         string
+	allFromList bool
+}
+
+var (
+	approveOptions   approvalOptions
+	unapproveOptions approvalOptions
+)
+
+// approvalTarget is a merge request to approve or unapprove, optionally pinned to a head SHA.
+type approvalTarget struct {
+	ref mrs.Reference
+	sha string
+}
+
+func init() {
+	rootCmd.AddCommand(approveCmd)
+	rootCmd.AddCommand(unapproveCmd)
+
+	flags.AddQueryFlags(approveCmd)
+	approveCmd.Flags().StringVar(&approveOptions.comment, "comment", "", "Comment on each MR after approving it.")
+	approveCmd.Flags().StringVar(&approveOptions.sha, "sha", "", "Only approve if this is still the MR's head commit.")
+	approveCmd.Flags().BoolVar(&approveOptions.allFromList, "all-from-list", false, "Approve every MR in the list queue, after confirming.")
+
+	flags.AddQueryFlags(unapproveCmd)
+	unapproveCmd.Flags().StringVar(&unapproveOptions.comment, "comment", "", "Comment on each MR after unapproving it.")
+	unapproveCmd.Flags().BoolVar(&unapproveOptions.allFromList, "all-from-list", false, "Unapprove every MR in the list queue you approved, after confirming.")
+}
+
+var approveCmd = &cobra.Command{
+	Use:   "approve [<project>!<iid>|<url>...]",
+	Short: "Approve merge requests",
+	Long: `approve
+
+Approves merge requests, given as <project>!<iid>, e.g. acme/web!42, or as URLs.
+
+With --sha, GitLab only approves the MR if that's still its head commit, so you never approve code you haven't seen.
+With --all-from-list, approve runs the same query as list and approves every MR in the queue you didn't author,
+each pinned to the head commit list saw, after asking you to confirm.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runApprovals(args, true, approveOptions)
+	},
+}
+
+var unapproveCmd = &cobra.Command{
+	Use:   "unapprove [<project>!<iid>|<url>...]",
+	Short: "Withdraw your approval of merge requests",
+	Long: `unapprove
+
+Withdraws your approval of merge requests, given as <project>!<iid>, e.g. acme/web!42, or as URLs.
+
+With --all-from-list, unapprove runs the same query as list and unapproves every MR in the queue you approved,
+after asking you to confirm.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runApprovals(args, false, unapproveOptions)
+	},
+}
+
+func runApprovals(args []string, approve bool, options approvalOptions) {
+	if options.allFromList == (len(args) > 0) {
+		log.Printf("Invalid arguments: give either merge requests or --all-from-list")
+		return
+	}
+	if options.sha != "" && len(args) != 1 {
+		log.Printf("Invalid flag: --sha only applies to a single merge request")
+		return
+	}
+
+	var targets []approvalTarget
+	for _, arg := range args {
+		ref, err := mrs.ParseReference(arg)
+		if err != nil {
+			log.Printf("Invalid argument: %v", err)
+			return
+		}
+		targets = append(targets, approvalTarget{ref: ref, sha: options.sha})
+	}
+
+	session, err := newListSession(sessionOptions{forceRefresh: true})
+	if err != nil {
+		log.Print(err)
+		return
+	}
+
+	if options.allFromList {
+		queue, err := approvalQueue(session, approve)
+		if err != nil {
+			log.Printf("Failed to fetch merge requests: %v", err)
+			return
+		}
+		if len(queue) == 0 {
+			fmt.Printf("macglab: there's nothing to %s.\n", approvalVerb(approve))
+			return
+		}
+
+		mrs.PrintMergeRequests(queue)
+		verb := approvalVerb(approve)
+		question := fmt.Sprintf("%s%s these %s? (yes/no): ", strings.ToUpper(verb[:1]), verb[1:], pluralMrs(len(queue)))
+		if response := utils.AskBinaryQuestion(question); !strings.HasPrefix(strings.ToLower(response), "y") {
+			return
+		}
+
+		for _, mr := range queue {
+			target := approvalTarget{ref: mrs.NewReference(mr)}
+			if approve {
+				target.sha = mr.SHA
+			}
+			targets = append(targets, target)
+		}
+	}
+
+	if err := applyApprovals(session.actions, targets, approve, options.comment, os.Stdout); err != nil {
+		log.Print(err)
+	}
+}
+
+// approvalQueue runs list's query for --all-from-list. Approving skips MRs you authored, since
+// GitLab won't let you approve them. Unapproving includes approved MRs, then keeps the ones you approved.
+func approvalQueue(session *listSession, approve bool) ([]*gitlab.MergeRequest, error) {
+	me := session.listFlags.Resolved.Me
+	if me == 0 {
+		return nil, errors.New("--all-from-list needs to know who you are; set me in your config or use --me")
+	}
+
+	if !approve {
+		session.listFlags.Boolean.Approved = true
+	}
+
+	queue, err := session.fetchMergeRequests()
+	if err != nil {
+		return nil, err
+	}
+
+	if approve {
+		notMine := []*gitlab.MergeRequest{}
+		for _, mr := range queue {
+			if mr.Author == nil || mr.Author.ID != me {
+				notMine = append(notMine, mr)
+			}
+		}
+		return notMine, nil
+	}
+
+	approvedMrs, err := session.source.GetMergeRequestsApprovedByMe(session.listFlags.Resolved.GroupId, me, &session.listFlags.Boolean.Draft)
+	if err != nil {
+		return nil, err
+	}
+	approvedUrls := map[string]bool{}
+	for _, mr := range approvedMrs {
+		approvedUrls[mr.WebURL] = true
+	}
+
+	approvedByMe := []*gitlab.MergeRequest{}
+	for _, mr := range queue {
+		if approvedUrls[mr.WebURL] {
+			approvedByMe = append(approvedByMe, mr)
+		}
+	}
+	return approvedByMe, nil
+}
+
+// applyApprovals approves or unapproves each target, then comments on it if comment isn't empty.
+// It keeps going when one fails, and returns an error saying how many did.
+func applyApprovals(actions mrs.MergeRequestActions, targets []approvalTarget, approve bool, comment string, w io.Writer) error {
+	failures := 0
+
+	for _, target := range targets {
+		var err error
+		if approve {
+			err = actions.ApproveMergeRequest(target.ref, target.sha)
+		} else {
+			err = actions.UnapproveMergeRequest(target.ref)
+		}
+		if err != nil {
+			log.Print(err)
+			failures++
+			continue
+		}
+		fmt.Fprintf(w, "macglab: %sd %s.\n", approvalVerb(approve), target.ref)
+
+		if comment != "" {
+			if err := actions.CommentOnMergeRequest(target.ref, comment); err != nil {
+				log.Print(err)
+				failures++
+			}
+		}
+	}
+
+	if failures > 0 {
+		return fmt.Errorf("%d of %s failed", failures, pluralMrs(len(targets)))
+	}
+	return nil
+}
+
+func approvalVerb(approve bool) string {
+	if approve {
+		return "approve"
+	}
+	return "unapprove"
+}
+
+func pluralMrs(count int) string {
+	if count == 1 {
+		return "1 MR"
+	}
+	return fmt.Sprintf("%d MRs", count)
+}
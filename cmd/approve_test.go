@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/mjburtenshaw/macglab/fakegitlab"
+	"github.com/mjburtenshaw/macglab/mrs"
+)
+
+func fixtureById(t *testing.T, server *fakegitlab.Server, id int) fakegitlab.Fixture {
+	t.Helper()
+	for _, fixture := range server.Fixtures() {
+		if fixture.ID == id {
+			return fixture
+		}
+	}
+	t.Fatalf("no fixture with ID %d", id)
+	return fakegitlab.Fixture{}
+}
+
+func TestApplyApprovals(t *testing.T) {
+	source, server := newFakeGitlab(t)
+
+	targets := []approvalTarget{
+		{ref: mrs.Reference{Project: "acme/project-1", IID: 1}, sha: "a1b2c3"},
+		{ref: mrs.Reference{Project: "3", IID: 1}},
+	}
+
+	var output bytes.Buffer
+	if err := applyApprovals(source, targets, true, "LGTM", &output); err != nil {
+		t.Fatalf("applyApprovals() error = %v", err)
+	}
+
+	wantOutput := "macglab: approved acme/project-1!1.\nmacglab: approved 3!1.\n"
+	if output.String() != wantOutput {
+		t.Errorf("output = %q, want %q", output.String(), wantOutput)
+	}
+
+	for _, id := range []int{101, 105} {
+		fixture := fixtureById(t, server, id)
+		if !reflect.DeepEqual(fixture.ApprovedByIds, []int{fakegitlab.DefaultUserId}) {
+			t.Errorf("MR %d approved by %v, want [%d]", id, fixture.ApprovedByIds, fakegitlab.DefaultUserId)
+		}
+		lastNote := fixture.Notes[len(fixture.Notes)-1]
+		if lastNote.Body != "LGTM" || lastNote.System {
+			t.Errorf("MR %d's last note = %q, want the comment", id, lastNote.Body)
+		}
+	}
+
+	output.Reset()
+	if err := applyApprovals(source, targets[:1], false, "", &output); err != nil {
+		t.Fatalf("applyApprovals() error = %v", err)
+	}
+	if fixture := fixtureById(t, server, 101); len(fixture.ApprovedByIds) != 0 {
+		t.Errorf("MR 101 approved by %v after unapproving, want none", fixture.ApprovedByIds)
+	}
+}
+
+func TestApplyApprovalsShaMismatch(t *testing.T) {
+	source, server := newFakeGitlab(t)
+
+	targets := []approvalTarget{
+		{ref: mrs.Reference{Project: "acme/project-1", IID: 1}, sha: "0ld5ha"},
+		{ref: mrs.Reference{Project: "acme/project-1", IID: 3}},
+	}
+
+	var output bytes.Buffer
+	err := applyApprovals(source, targets, true, "LGTM", &output)
+	if err == nil || err.Error() != "1 of 2 MRs failed" {
+		t.Fatalf("applyApprovals() error = %v, want 1 of 2 MRs failed", err)
+	}
+
+	fixture := fixtureById(t, server, 101)
+	if len(fixture.ApprovedByIds) != 0 {
+		t.Errorf("MR 101 approved by %v despite the SHA mismatch", fixture.ApprovedByIds)
+	}
+	if len(fixture.Notes) != 0 {
+		t.Errorf("MR 101 was commented on despite the SHA mismatch")
+	}
+	if fixture := fixtureById(t, server, 109); len(fixture.ApprovedByIds) != 1 {
+		t.Errorf("MR 109 wasn't approved after MR 101 failed")
+	}
+}
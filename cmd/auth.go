@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/mjburtenshaw/macglab/auth"
+	"github.com/mjburtenshaw/macglab/config"
+	"github.com/mjburtenshaw/macglab/files"
+	"github.com/mjburtenshaw/macglab/utils"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(authCmd)
+	authCmd.AddCommand(authLoginCmd)
+	authCmd.AddCommand(authLogoutCmd)
+}
+
+var authCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "Keep your access token out of your config",
+	Long: `auth
+
+Stores your GitLab access token in your keyring: the macOS keychain, or the Secret Service via secret-tool elsewhere.
+Each profile has its own token.
+
+macglab looks for an access token in this order: --access-token, MACGLAB_ACCESS_TOKEN, access_token in your config,
+access_token_command in your config, then the keyring.`,
+}
+
+var authLoginCmd = &cobra.Command{
+	Use:   "login",
+	Short: "Store your access token in the keyring",
+	Long: `auth login
+
+Asks for your access token and stores it in the keyring for the current profile, or the one given with --profile.
+It also reads the token from stdin, e.g. pass show gitlab | macglab auth login.
+
+If your config has a plaintext access_token for the profile, it's removed.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		conf, err := readConfig()
+		if err != nil {
+			log.Printf("Failed to read config: %v", err)
+			return
+		}
+
+		token := utils.AskSecret(fmt.Sprintf("GitLab access token for profile %s: ", conf.Profile))
+		if token == "" {
+			log.Printf("Failed to log in: no access token given")
+			return
+		}
+
+		if err := saveAccessToken(files.MacglabConfigUrl, conf, token); err != nil {
+			log.Printf("Failed to log in: %v", err)
+		}
+	},
+}
+
+var authLogoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "Remove your access token from the keyring",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		conf, err := readConfig()
+		if err != nil {
+			log.Printf("Failed to read config: %v", err)
+			return
+		}
+
+		if err := auth.Forget(conf.Profile); err != nil {
+			if errors.Is(err, auth.ErrNotFound) {
+				fmt.Printf("macglab: there's no access token for profile %s in the keyring.\n", conf.Profile)
+				return
+			}
+			log.Printf("Failed to log out: %v", err)
+			return
+		}
+		fmt.Printf("macglab: removed the access token for profile %s from the keyring.\n", conf.Profile)
+	},
+}
+
+// saveAccessToken stores the access token for the config's profile in the keyring, then removes the
+// profile's plaintext access_token from the config, since it would be used instead.
+func saveAccessToken(configUrl string, conf *config.Config, token string) error {
+	if err := auth.Store(conf.Profile, token); err != nil {
+		return err
+	}
+	fmt.Printf("macglab: stored the access token for profile %s in the keyring.\n", conf.Profile)
+
+	key := conf.KeyPath("access_token")
+	if _, err := config.Get(configUrl, key); err == nil {
+		if err := config.Unset(configUrl, key); err != nil {
+			return fmt.Errorf("couldn't remove %s from %s: %w", key, configUrl, err)
+		}
+		fmt.Printf("macglab: removed %s from %s.\n", key, configUrl)
+	}
+
+	if updated, err := config.ReadProfile(configUrl, conf.Profile); err == nil && (updated.AccessToken != "" || updated.AccessTokenCommand != "") {
+		fmt.Printf("macglab: access_token or access_token_command is still set for profile %s, so the keyring won't be used.\n", conf.Profile)
+	}
+
+	return nil
+}
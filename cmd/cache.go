@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/mjburtenshaw/macglab/cache"
+	"github.com/mjburtenshaw/macglab/files"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheClearCmd)
+}
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage cached GitLab responses",
+	Long: `cache
+
+Manages the GitLab responses macglab caches at ~/.macglab/cache.
+
+Responses are served from the cache until they're older than the configured cache_ttl, then revalidated with GitLab.`,
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Deletes cached GitLab responses",
+	Long: `clear
+
+Deletes every cached GitLab response at ~/.macglab/cache.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := cache.Clear(files.MacglabCacheUri); err != nil {
+			log.Printf("Failed to clear cache: %v", err)
+			return
+		}
+		fmt.Println("macglab: cleared the cache.")
+	},
+}
@@ -0,0 +1,421 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/mjburtenshaw/macglab/cache"
+	"github.com/mjburtenshaw/macglab/config"
+	"github.com/mjburtenshaw/macglab/files"
+	"github.com/mjburtenshaw/macglab/mrs"
+	"github.com/mjburtenshaw/macglab/notify"
+	"github.com/mjburtenshaw/macglab/utils"
+	"github.com/spf13/cobra"
+	"github.com/xanzy/go-gitlab"
+	"gopkg.in/yaml.v2"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// redacted replaces secrets when printing the config.
+const redacted = "<redacted>"
+
+// defaultBaseUrl is where GitLab lives when base_url isn't set.
+const defaultBaseUrl = "https://gitlab.com"
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configAddCmd)
+	configCmd.AddCommand(configUnsetCmd)
+	configCmd.AddCommand(configListCmd)
+	configCmd.AddCommand(configEditCmd)
+	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configProjectsCmd)
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Read and change your config",
+	Long: `config
+
+Reads and changes the config at ~/.macglab/config.yml.
+
+Keys are dotted paths, e.g. usernames, projects.123 or notifiers.0.url.
+Values are parsed as YAML, e.g. 5 is a number and '[alice, bob]' is a list.
+Changes that would make the config invalid aren't written.`,
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print a config value",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		value, err := config.Get(files.MacglabConfigUrl, args[0])
+		if err != nil {
+			log.Printf("Failed to get %s: %v", args[0], err)
+			return
+		}
+
+		if err := writeConfigValue(os.Stdout, value); err != nil {
+			log.Printf("Failed to print %s: %v", args[0], err)
+		}
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Set a config value",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := config.Set(files.MacglabConfigUrl, args[0], args[1]); err != nil {
+			log.Printf("Failed to set %s: %v", args[0], err)
+			return
+		}
+		fmt.Printf("macglab: set %s.\n", args[0])
+	},
+}
+
+var configAddCmd = &cobra.Command{
+	Use:   "add <key> <value>...",
+	Short: "Append values to a config list, e.g. usernames",
+	Args:  cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := config.Add(files.MacglabConfigUrl, args[0], args[1:]); err != nil {
+			log.Printf("Failed to add to %s: %v", args[0], err)
+			return
+		}
+		fmt.Printf("macglab: added to %s.\n", args[0])
+	},
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:   "unset <key>",
+	Short: "Remove a config value, or an item from a list, e.g. usernames.0",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := config.Unset(files.MacglabConfigUrl, args[0]); err != nil {
+			log.Printf("Failed to unset %s: %v", args[0], err)
+			return
+		}
+		fmt.Printf("macglab: unset %s.\n", args[0])
+	},
+}
+
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Print the effective config for the current profile, with defaults filled in and secrets redacted",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		conf, err := readConfig()
+		if err != nil {
+			log.Printf("Failed to read config: %v", err)
+			return
+		}
+
+		data, err := yaml.Marshal(redactConfig(effectiveConfig(conf)))
+		if err != nil {
+			log.Printf("Failed to marshal config: %v", err)
+			return
+		}
+		fmt.Printf("# profile: %s\n", conf.Profile)
+		for _, name := range config.EnvOverrides() {
+			fmt.Printf("# overridden by %s\n", name)
+		}
+		os.Stdout.Write(data)
+	},
+}
+
+var configEditCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "Open your config in $EDITOR, then validate it",
+	Long: `config edit
+
+Opens a copy of your config in $VISUAL or $EDITOR, defaulting to vi.
+Your config is only replaced once the copy is valid. If it isn't, you can edit it again or discard your changes.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := editConfig(files.MacglabConfigUrl, runEditor); err != nil {
+			log.Printf("Failed to edit config: %v", err)
+		}
+	},
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check your config for mistakes",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		data, err := os.ReadFile(files.MacglabConfigUrl)
+		if err != nil {
+			log.Printf("Failed to read config: %v", err)
+			return
+		}
+
+		if err := validateConfig(data); err != nil {
+			log.Printf("Invalid config: %v", err)
+			return
+		}
+		fmt.Printf("macglab: %s is valid.\n", files.MacglabConfigUrl)
+	},
+}
+
+var configProjectsCmd = &cobra.Command{
+	Use:   "projects",
+	Short: "Print the ID and path of each configured project, expanding wildcards",
+	Long: `config projects
+
+Looks up each key under projects in GitLab and prints the project's ID and path, so you can use either form.
+Wildcards like platform/* are expanded to the projects GitLab has in that namespace right now.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		session, err := newListSession(sessionOptions{skipMe: true})
+		if err != nil {
+			log.Print(err)
+			return
+		}
+
+		if err := writeProjects(os.Stdout, session.source, session.conf.Projects); err != nil {
+			log.Printf("Failed to resolve projects: %v", err)
+		}
+	},
+}
+
+// writeProjects prints each projects key with the ID and path of the projects it refers to.
+func writeProjects(w io.Writer, source mrs.MergeRequestSource, projects map[string][]string) error {
+	keys := make([]string, 0, len(projects))
+	for key := range projects {
+		if key != config.AllProjects {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	if len(keys) == 0 {
+		_, err := fmt.Fprintln(w, "macglab: there are no projects in your config.")
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEY\tID\tPATH")
+	for _, key := range keys {
+		var keyProjects []*gitlab.Project
+		if namespace, includeSubgroups, ok := config.ProjectPattern(key); ok {
+			namespaceProjects, err := source.FetchNamespaceProjects(namespace, includeSubgroups)
+			if err != nil {
+				return err
+			}
+			keyProjects = namespaceProjects
+		} else {
+			project, err := source.FetchProject(key)
+			if err != nil {
+				return err
+			}
+			keyProjects = []*gitlab.Project{project}
+		}
+
+		if len(keyProjects) == 0 {
+			fmt.Fprintf(tw, "%s\t-\tno projects\n", key)
+		}
+		for i, project := range keyProjects {
+			label := key
+			if i > 0 {
+				label = ""
+			}
+			fmt.Fprintf(tw, "%s\t%d\t%s\n", label, project.ID, project.PathWithNamespace)
+		}
+	}
+	return tw.Flush()
+}
+
+// validateConfig parses a config and checks the parts other packages interpret, like notifiers and templates.
+func validateConfig(data []byte) error {
+	conf, err := config.Parse(data)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	if _, err := notify.New(conf.Notifiers); err != nil {
+		errs = append(errs, err)
+	}
+	var names []string
+	for name := range conf.Templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, err := mrs.ParseFormat(conf.Templates[name], nil); err != nil {
+			errs = append(errs, fmt.Errorf("template %s: %w", name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// editConfig has the user edit a copy of the config until it's valid or they give up, then replaces the config.
+func editConfig(configUrl string, openEditor func(fileUrl string) error) error {
+	original, err := os.ReadFile(configUrl)
+	if err != nil {
+		return fmt.Errorf("couldn't read %s: %w", configUrl, err)
+	}
+
+	draft, err := os.CreateTemp(filepath.Dir(configUrl), "config.*.yml")
+	if err != nil {
+		return fmt.Errorf("couldn't create a copy of %s: %w", configUrl, err)
+	}
+	defer os.Remove(draft.Name())
+
+	if _, err := draft.Write(original); err != nil {
+		draft.Close()
+		return fmt.Errorf("couldn't create a copy of %s: %w", configUrl, err)
+	}
+	if err := draft.Close(); err != nil {
+		return fmt.Errorf("couldn't create a copy of %s: %w", configUrl, err)
+	}
+
+	for {
+		if err := openEditor(draft.Name()); err != nil {
+			return err
+		}
+
+		edited, err := os.ReadFile(draft.Name())
+		if err != nil {
+			return fmt.Errorf("couldn't read your changes: %w", err)
+		}
+
+		if bytes.Equal(edited, original) {
+			fmt.Println("macglab: no changes.")
+			return nil
+		}
+
+		if err := validateConfig(edited); err != nil {
+			fmt.Printf("macglab: your changes make the config invalid: %v\n", err)
+			response := utils.AskBinaryQuestion("Edit again? (yes/no): ")
+			if strings.HasPrefix(strings.ToLower(response), "y") {
+				continue
+			}
+			fmt.Println("macglab: discarded your changes.")
+			return nil
+		}
+
+		if err := config.Write(configUrl, edited); err != nil {
+			return err
+		}
+		fmt.Printf("macglab: saved %s.\n", configUrl)
+		return nil
+	}
+}
+
+// runEditor opens fileUrl in $VISUAL or $EDITOR, which may include arguments, e.g. `code --wait`.
+func runEditor(fileUrl string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	fields := strings.Fields(editor)
+	editorCmd := exec.Command(fields[0], append(fields[1:], fileUrl)...)
+	editorCmd.Stdin = os.Stdin
+	editorCmd.Stdout = os.Stdout
+	editorCmd.Stderr = os.Stderr
+
+	if err := editorCmd.Run(); err != nil {
+		return fmt.Errorf("couldn't run %s: %w", editor, err)
+	}
+	return nil
+}
+
+// effectiveConfig fills in the defaults macglab uses for unset values.
+func effectiveConfig(conf *config.Config) *config.Config {
+	effective := *conf
+	if effective.BaseUrl == "" {
+		effective.BaseUrl = defaultBaseUrl
+	}
+	if effective.CacheTTL == "" {
+		effective.CacheTTL = cache.DefaultTTL.String()
+	}
+	if effective.Concurrency <= 0 {
+		effective.Concurrency = mrs.DefaultConcurrency
+	}
+	if effective.MaxPages <= 0 {
+		effective.MaxPages = mrs.DefaultMaxPages
+	}
+	if effective.PerPage <= 0 {
+		effective.PerPage = mrs.DefaultPerPage
+	}
+	return &effective
+}
+
+// redactConfig returns a copy of the config that's safe to share: the access token, notifier
+// headers and the paths of notifier URLs, which often embed tokens, are replaced. Profiles are
+// left out, since the config is already the effective one for its profile.
+func redactConfig(conf *config.Config) *config.Config {
+	redactedConf := *conf
+	redactedConf.Profiles = nil
+	if redactedConf.AccessToken != "" {
+		redactedConf.AccessToken = redacted
+	}
+
+	redactedConf.Notifiers = nil
+	for _, notifier := range conf.Notifiers {
+		if notifier.Url != "" {
+			notifier.Url = redactUrl(notifier.Url)
+		}
+		if len(notifier.Headers) > 0 {
+			headers := map[string]string{}
+			for name := range notifier.Headers {
+				headers[name] = redacted
+			}
+			notifier.Headers = headers
+		}
+		redactedConf.Notifiers = append(redactedConf.Notifiers, notifier)
+	}
+
+	return &redactedConf
+}
+
+// redactUrl keeps only the scheme and host of a URL, e.g. `https://hooks.slack.com/<redacted>`.
+// Credentials in the URL are always dropped.
+func redactUrl(value string) string {
+	parsedUrl, err := url.Parse(value)
+	if err != nil || parsedUrl.Host == "" {
+		return redacted
+	}
+	if parsedUrl.Path == "" && parsedUrl.RawQuery == "" && parsedUrl.Fragment == "" {
+		return fmt.Sprintf("%s://%s", parsedUrl.Scheme, parsedUrl.Host)
+	}
+	return fmt.Sprintf("%s://%s/%s", parsedUrl.Scheme, parsedUrl.Host, redacted)
+}
+
+// writeConfigValue prints scalars as they are and everything else as YAML.
+func writeConfigValue(w io.Writer, value *yamlv3.Node) error {
+	if value.Kind == yamlv3.ScalarNode {
+		if value.ShortTag() == "!!null" {
+			_, err := fmt.Fprintln(w)
+			return err
+		}
+		_, err := fmt.Fprintln(w, value.Value)
+		return err
+	}
+
+	encoder := yamlv3.NewEncoder(w)
+	encoder.SetIndent(4)
+	if err := encoder.Encode(value); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/mjburtenshaw/macglab/config"
+	"github.com/mjburtenshaw/macglab/flags"
+	"github.com/mjburtenshaw/macglab/mrs"
+	"github.com/mjburtenshaw/macglab/utils"
+	"github.com/spf13/cobra"
+	"github.com/xanzy/go-gitlab"
+)
+
+var (
+	digestStale string
+	digestSince string
+)
+
+func init() {
+	rootCmd.AddCommand(digestCmd)
+	flags.AddQueryFlags(digestCmd)
+	digestCmd.Flags().StringVar(&digestStale, "stale", "2d", "Highlight MRs waiting longer than this, e.g. 36h or 2d.")
+	digestCmd.Flags().StringVar(&digestSince, "since", "", "Include MRs merged or closed in this window, e.g. 24h or 1w, or since a date like 2023-09-01.")
+}
+
+var digestCmd = &cobra.Command{
+	Use:   "digest",
+	Short: "Summarize the queue in markdown",
+	Long: `digest
+
+Runs the same query as list and prints a markdown report of the queue, grouped by project, ready to paste into chat.
+It highlights MRs waiting longer than --stale, MRs with failing pipelines and MRs with no reviewers.
+
+With --since, it also lists MRs by the usernames you follow that were merged or closed in that window.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		staleAfter, err := utils.ParseDuration(digestStale)
+		if err != nil || staleAfter < 0 {
+			log.Printf("Invalid flag: --stale must be a duration like 36h or 2d")
+			return
+		}
+
+		var since *time.Time
+		if digestSince != "" {
+			sinceTime, err := parsePastTime("--since", digestSince, time.Now())
+			if err != nil {
+				log.Printf("Invalid flag: %v", err)
+				return
+			}
+			since = &sinceTime
+		}
+
+		session, err := newListSession(sessionOptions{})
+		if err != nil {
+			log.Print(err)
+			return
+		}
+
+		allMrs, err := session.fetchMergeRequests()
+		if err != nil {
+			log.Printf("Failed to fetch merge requests: %v", err)
+			return
+		}
+
+		concurrency := session.listFlags.Resolved.Concurrency
+		details, err := mrs.FetchDetails(session.source, allMrs, concurrency)
+		if err != nil {
+			log.Printf("Failed to fetch merge request details: %v", err)
+			return
+		}
+
+		if err := mrs.SortMergeRequests(allMrs, mrs.SortByAge, false, mrs.SortOptions{}); err != nil {
+			log.Printf("Failed to sort merge requests: %v", err)
+			return
+		}
+
+		options := mrs.DigestOptions{StaleAfter: staleAfter, Details: details, Since: since}
+		if since != nil {
+			options.Finished, err = fetchFinishedMergeRequests(session.source, session.conf, session.listFlags.Resolved, session.listFlags.Boolean, *since)
+			if err != nil {
+				log.Printf("Failed to fetch merged and closed merge requests: %v", err)
+				return
+			}
+		}
+
+		if err := mrs.WriteDigest(os.Stdout, allMrs, options); err != nil {
+			log.Printf("Failed to write digest: %v", err)
+		}
+	},
+}
+
+// parsePastTime parses a flag given as a duration before now, e.g. `24h` or `1w`, or as a date.
+func parsePastTime(flagName string, value string, now time.Time) (time.Time, error) {
+	if duration, err := utils.ParseDuration(value); err == nil {
+		if duration < 0 {
+			return time.Time{}, fmt.Errorf("%s must not be negative", flagName)
+		}
+		return now.Add(-duration), nil
+	}
+
+	date, err := time.ParseInLocation("2006-01-02", value, now.Location())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be a duration like 24h or 1w, or a date like 2023-09-01", flagName)
+	}
+	return date, nil
+}
+
+// fetchFinishedMergeRequests fetches MRs by the usernames list follows that were merged or
+// closed since the given time, most recently finished first.
+func fetchFinishedMergeRequests(source mrs.MergeRequestSource, conf *config.Config, resolvedFlags flags.ResolvedFlags, booleanFlags flags.BooleanFlags, since time.Time) ([]*gitlab.MergeRequest, error) {
+	var fetches []mrs.Fetch
+
+	if (!booleanFlags.Group && !booleanFlags.Projects) || booleanFlags.Group {
+		for _, username := range chooseUsernames(resolvedFlags.Usernames, conf.Usernames) {
+			username := username
+			fetches = append(fetches, func() ([]*gitlab.MergeRequest, error) {
+				return source.FetchGroupMergeRequestsFinishedSince(resolvedFlags.GroupId, username, since)
+			})
+		}
+	}
+
+	if (!booleanFlags.Group && !booleanFlags.Projects) || booleanFlags.Projects {
+		for _, project := range sortedProjects(conf) {
+			project := project
+			for _, username := range chooseUsernames(resolvedFlags.Usernames, projectUsernames(conf, project)) {
+				username := username
+				fetches = append(fetches, func() ([]*gitlab.MergeRequest, error) {
+					return source.FetchProjectMergeRequestsFinishedSince(project, username, since)
+				})
+			}
+		}
+	}
+
+	results, err := mrs.FetchConcurrently(fetches, resolvedFlags.Concurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	var finishedMrs []*gitlab.MergeRequest
+	for _, result := range results {
+		finishedMrs = append(finishedMrs, result...)
+	}
+	finishedMrs = dedupeMergeRequests(finishedMrs)
+
+	sort.SliceStable(finishedMrs, func(i, j int) bool {
+		return mrs.FinishedAt(finishedMrs[i]).After(*mrs.FinishedAt(finishedMrs[j]))
+	})
+
+	return finishedMrs, nil
+}
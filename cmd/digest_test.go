@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mjburtenshaw/macglab/flags"
+)
+
+func TestFetchFinishedMergeRequests(t *testing.T) {
+	tests := []struct {
+		name    string
+		since   time.Time
+		boolean flags.BooleanFlags
+		want    []int
+	}{
+		{
+			name:  "most recently finished first, leaving out MRs only updated in the window",
+			since: time.Date(2023, 9, 1, 0, 0, 0, 0, time.UTC),
+			want:  []int{111, 110},
+		},
+		{
+			name:  "includes MRs finished in a wider window",
+			since: time.Date(2023, 8, 1, 0, 0, 0, 0, time.UTC),
+			want:  []int{111, 110, 112},
+		},
+		{
+			name:  "leaves out MRs finished before since",
+			since: time.Date(2023, 9, 11, 0, 0, 0, 0, time.UTC),
+			want:  []int{111},
+		},
+		{
+			name:    "group only uses configured usernames",
+			since:   time.Date(2023, 9, 1, 0, 0, 0, 0, time.UTC),
+			boolean: flags.BooleanFlags{Group: true},
+			want:    []int{110},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolvedFlags := flags.ResolvedFlags{GroupId: "42", Me: 7, Concurrency: 2}
+
+			got, err := fetchFinishedMergeRequests(newFakeSource(t), testConfig(), resolvedFlags, tt.boolean, tt.since)
+			if err != nil {
+				t.Fatalf("fetchFinishedMergeRequests() error = %v", err)
+			}
+
+			if ids := mergeRequestIds(got); !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("fetchFinishedMergeRequests() = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePastTime(t *testing.T) {
+	now := time.Date(2023, 9, 4, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{value: "24h", want: time.Date(2023, 9, 3, 10, 0, 0, 0, time.UTC)},
+		{value: "1w", want: time.Date(2023, 8, 28, 10, 0, 0, 0, time.UTC)},
+		{value: "2023-09-01", want: time.Date(2023, 9, 1, 0, 0, 0, 0, time.UTC)},
+		{value: "yesterday", wantErr: true},
+		{value: "-2d", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parsePastTime("--since", tt.value, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePastTime() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !got.Equal(tt.want) {
+				t.Errorf("parsePastTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,13 +2,17 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"log"
+	"os"
+	"sort"
+	"text/template"
 
 	"github.com/mjburtenshaw/macglab/config"
 	"github.com/mjburtenshaw/macglab/files"
 	"github.com/mjburtenshaw/macglab/flags"
-	"github.com/mjburtenshaw/macglab/glab"
 	"github.com/mjburtenshaw/macglab/mrs"
+	"github.com/mjburtenshaw/macglab/utils"
 	"github.com/spf13/cobra"
 	"github.com/xanzy/go-gitlab"
 )
@@ -25,6 +29,12 @@ var listCmd = &cobra.Command{
 
 Prints GitLab Merge Request (MRs) authors and URLs to the terminal.
 
+Use --output json, ndjson or yaml to print every MR's details in a stable, versioned schema instead.
+Use --output table to print aligned columns (see --columns).
+Use --sort to order MRs, e.g. --sort age to put the oldest waiting review at the top.
+Use --group-by to print MRs in sections by project, author, reviewer, label or status.
+Use --format to print each MR with a Go template, e.g. --format '{{.Author.Username}}\t{{.Title}}\t{{.WebURL}}'.
+
 list fetches MRs meeting ALL the following criteria:
 - State is open.
 - Belongs to the configured group ID.
@@ -38,33 +48,117 @@ list then excludes MRs meeting the following criteria:
 - Approved by you.
 - Mergeable MRs where you are NOT the author.
 
-Note: group and projects are not mutually exclusive. If neither are provided, the program will run as if both are provided.`,
+Note: group and projects are not mutually exclusive. If neither are provided, the program will run as if both are provided.
+
+Queries run concurrently (see --concurrency); output order is the same between runs.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		conf, err := config.Read(files.MacglabConfigUrl)
+		// Check the display flags before connecting to GitLab, so a typo fails fast.
+		conf, err := readConfig()
 		if err != nil {
 			log.Printf("Failed to read config: %v", err)
-            return
+			return
 		}
+		displayFlags := flags.GetListFlags(conf).Display
 
-		listFlags := flags.GetListFlags(conf)
+		if err := mrs.ValidateOutputFormat(displayFlags.Output); err != nil {
+			log.Printf("Invalid flag: %v", err)
+			return
+		}
 
-		glabClient, err := glab.Initialize(listFlags.Resolved.AccessToken)
+		columns, err := mrs.ParseColumns(displayFlags.ColumnsRaw)
 		if err != nil {
-			log.Printf("Failed to initialize gitlab client: %v", err)
-            return
+			log.Printf("Invalid flag: %v", err)
+			return
+		}
+		if displayFlags.Output != mrs.OutputTable {
+			if displayFlags.ColumnsRaw != "" {
+				log.Printf("Invalid flag: --columns can't be used with --output %s", displayFlags.Output)
+				return
+			}
+			if displayFlags.NoHeader {
+				log.Printf("Invalid flag: --no-header can't be used with --output %s", displayFlags.Output)
+				return
+			}
+		}
+
+		if displayFlags.GroupBy != "" {
+			if err := mrs.ValidateGroupBy(displayFlags.GroupBy); err != nil {
+				log.Printf("Invalid flag: %v", err)
+				return
+			}
+			if displayFlags.Output != mrs.OutputText && displayFlags.Output != mrs.OutputTable {
+				log.Printf("Invalid flag: --group-by can't be used with --output %s", displayFlags.Output)
+				return
+			}
 		}
 
-		allMrs, err := fetchMergeRequests(glabClient, conf, listFlags.Resolved, listFlags.Boolean)
+		if displayFlags.Sort != "" {
+			if err := mrs.ValidateSortKey(displayFlags.Sort); err != nil {
+				log.Printf("Invalid flag: %v", err)
+				return
+			}
+		}
+
+		var formatTemplate *template.Template
+		if displayFlags.Format != "" {
+			if displayFlags.Output != mrs.OutputText {
+				log.Printf("Invalid flag: --format can't be used with --output %s", displayFlags.Output)
+				return
+			}
+			formatTemplate, err = mrs.ParseFormat(displayFlags.Format, conf.Templates)
+			if err != nil {
+				log.Printf("Invalid flag: %v", err)
+				return
+			}
+		}
+
+		session, err := newListSession(sessionOptions{conf: conf})
+		if err != nil {
+			log.Print(err)
+			return
+		}
+		listFlags, source := session.listFlags, session.source
+
+		allMrs, err := session.fetchMergeRequests()
 		if err != nil {
 			log.Printf("Failed to fetch merge requests: %v", err)
             return
 		}
 
+		// Only fetch pipelines, approvals and sizes if a table column or the sort needs them.
+		var details map[int]*mrs.MergeRequestDetails
+		needsTableDetails := formatTemplate == nil && listFlags.Display.Output == mrs.OutputTable && mrs.NeedsDetails(columns)
+		if needsTableDetails || mrs.SortNeedsDetails(listFlags.Display.Sort) {
+			details, err = mrs.FetchDetails(source, allMrs, listFlags.Resolved.Concurrency)
+			if err != nil {
+				log.Printf("Failed to fetch merge request details: %v", err)
+				return
+			}
+		}
+
+		if listFlags.Display.Sort != "" {
+			if err := mrs.SortMergeRequests(allMrs, listFlags.Display.Sort, listFlags.Display.Reverse, mrs.SortOptions{
+				Me:      listFlags.Resolved.Me,
+				Details: details,
+			}); err != nil {
+				log.Printf("Failed to sort merge requests: %v", err)
+				return
+			}
+		}
+
 		if listFlags.Boolean.Count {
-			fmt.Printf("count: %v\n", len(allMrs))
+			// Keep machine-readable output parseable by counting on stderr.
+			countOutput := os.Stdout
+			if listFlags.Display.Output != mrs.OutputText {
+				countOutput = os.Stderr
+			}
+			fmt.Fprintf(countOutput, "count: %v\n", len(allMrs))
 		}
 
-		mrs.PrintMergeRequests(allMrs)
+		if err := printMergeRequests(os.Stdout, allMrs, details, columns, formatTemplate, listFlags); err != nil {
+			log.Printf("Failed to print merge requests: %v", err)
+			return
+		}
 
 		if listFlags.Boolean.Browser {
 			if err := mrs.OpenMergeRequests(allMrs); err != nil {
@@ -76,67 +170,94 @@ Note: group and projects are not mutually exclusive. If neither are provided, th
 			{
 				ShouldAsk:  listFlags.TrueUp["shouldAskToUpdateAccessToken"],
 				Question:   "Do you want to use the same access token in the future? (yes/no): ",
-				ConfigAttr: "access_token",
+				ConfigAttr: conf.KeyPath("access_token"),
 				NextValue:  listFlags.RawValue.AccessToken,
+				Store: func(token string) error {
+					return saveAccessToken(files.MacglabConfigUrl, conf, token)
+				},
+			},
+			{
+				ShouldAsk:  listFlags.TrueUp["shouldAskToUpdateBaseUrl"],
+				Question:   "Do you want to use the same base URL in the future? (yes/no): ",
+				ConfigAttr: conf.KeyPath("base_url"),
+				NextValue:  listFlags.RawValue.BaseUrl,
 			},
 			{
 				ShouldAsk:  listFlags.TrueUp["shouldAskToUpdateGroupId"],
 				Question:   "Do you want to use the same group ID in the future? (yes/no): ",
-				ConfigAttr: "group_id",
+				ConfigAttr: conf.KeyPath("group_id"),
 				NextValue:  listFlags.RawValue.GroupId,
 			},
 			{
 				ShouldAsk:  listFlags.TrueUp["shouldAskToUpdateMe"],
-				Question:   "Do you want to use the same me user ID in the future? (yes/no): ",
-				ConfigAttr: "me",
-				NextValue:  fmt.Sprintf("%d", listFlags.RawValue.Me),
+				Question:   "Do you want to use the same me in the future? (yes/no): ",
+				ConfigAttr: conf.KeyPath("me"),
+				NextValue:  listFlags.RawValue.Me,
 			},
 		})
 	},
 }
 
-func fetchMergeRequests(glabClient *glab.TGitlabClient, conf *config.Config, resolvedFlags flags.ResolvedFlags, booleanFlags flags.BooleanFlags) ([]*gitlab.MergeRequest, error) {
-	var allMrs []*gitlab.MergeRequest
+func fetchMergeRequests(source mrs.MergeRequestSource, conf *config.Config, resolvedFlags flags.ResolvedFlags, booleanFlags flags.BooleanFlags) ([]*gitlab.MergeRequest, error) {
+	var fetches []mrs.Fetch
 
 	if (!booleanFlags.Group && !booleanFlags.Projects) || booleanFlags.Group {
 		usernames := chooseUsernames(resolvedFlags.Usernames, conf.Usernames)
-		groupMrs, err := mrs.FetchGroupMergeRequests(glabClient, resolvedFlags.GroupId, usernames, &booleanFlags.Draft)
-		if err != nil {
-			return nil, err
+		for _, username := range usernames {
+			username := username
+			fetches = append(fetches, func() ([]*gitlab.MergeRequest, error) {
+				return source.FetchGroupMergeRequests(resolvedFlags.GroupId, []string{username}, &booleanFlags.Draft)
+			})
 		}
-		allMrs = append(allMrs, groupMrs...)
 	}
 
 	if (!booleanFlags.Group && !booleanFlags.Projects) || booleanFlags.Projects {
-		allProjectUsernames := conf.Projects["all"]
-
-		for project, thisProjectUsernames := range conf.Projects {
-			if project != "all" {
-				projectUsernames := append(thisProjectUsernames, allProjectUsernames...)
-				usernames := chooseUsernames(resolvedFlags.Usernames, projectUsernames)
-				projectMrs, err := mrs.FetchProjectMergeRequests(glabClient, project, usernames, &booleanFlags.Draft)
-				if err != nil {
-					return nil, err
-				}
-				allMrs = append(allMrs, projectMrs...)
+		for _, project := range sortedProjects(conf) {
+			project := project
+			usernames := chooseUsernames(resolvedFlags.Usernames, projectUsernames(conf, project))
+			for _, username := range usernames {
+				username := username
+				fetches = append(fetches, func() ([]*gitlab.MergeRequest, error) {
+					return source.FetchProjectMergeRequests(project, []string{username}, &booleanFlags.Draft)
+				})
 			}
 		}
 	}
 
-	mrsInReviewByMe, err := mrs.FetchReviewerMergeRequests(glabClient, resolvedFlags.GroupId, resolvedFlags.Me, &booleanFlags.Draft)
+	// Without knowing who you are, there's no reviewer to ask about.
+	if resolvedFlags.Me != 0 {
+		fetches = append(fetches, func() ([]*gitlab.MergeRequest, error) {
+			return source.FetchReviewerMergeRequests(resolvedFlags.GroupId, resolvedFlags.Me, &booleanFlags.Draft)
+		})
+	}
+
+	shouldExcludeApproved := !booleanFlags.Approved && resolvedFlags.Me != 0
+	if shouldExcludeApproved {
+		fetches = append(fetches, func() ([]*gitlab.MergeRequest, error) {
+			return source.GetMergeRequestsApprovedByMe(resolvedFlags.GroupId, resolvedFlags.Me, &booleanFlags.Draft)
+		})
+	}
+
+	results, err := mrs.FetchConcurrently(fetches, resolvedFlags.Concurrency)
 	if err != nil {
 		return nil, err
 	}
-	allMrs = append(allMrs, mrsInReviewByMe...)
+
+	var approvedMrs []*gitlab.MergeRequest
+	if shouldExcludeApproved {
+		approvedMrs = results[len(results)-1]
+		results = results[:len(results)-1]
+	}
+
+	var allMrs []*gitlab.MergeRequest
+	for _, result := range results {
+		allMrs = append(allMrs, result...)
+	}
 
 	allMrs = dedupeMergeRequests(allMrs)
 
-	if !booleanFlags.Approved && resolvedFlags.Me != 0 {
-		mrsNotApprovedByMe, err := excludeMrsApprovedByMe(glabClient, resolvedFlags.GroupId, resolvedFlags.Me, booleanFlags.Draft, allMrs)
-		if err != nil {
-			return nil, err
-		}
-		allMrs = mrsNotApprovedByMe
+	if shouldExcludeApproved {
+		allMrs = excludeMrsApprovedByMe(approvedMrs, allMrs)
 	}
 
 	// Filter out MRs that are ready to merge, unless the given `me` GitLab user ID is the author.
@@ -145,7 +266,7 @@ func fetchMergeRequests(glabClient *glab.TGitlabClient, conf *config.Config, res
 	if !booleanFlags.Ready {
 		mrsNotReadyToMerge := []*gitlab.MergeRequest{}
 		for _, mr := range allMrs {
-			if mr.DetailedMergeStatus != "mergeable"  || mr.Author.ID == resolvedFlags.Me {
+			if mr.DetailedMergeStatus != mrs.MergeableStatus  || mr.Author.ID == resolvedFlags.Me {
 				mrsNotReadyToMerge = append(mrsNotReadyToMerge, mr)
 			}
 		}
@@ -155,6 +276,115 @@ func fetchMergeRequests(glabClient *glab.TGitlabClient, conf *config.Config, res
 	return allMrs, nil
 }
 
+// sortedProjects lists the configured projects, except "all", in a stable order so results
+// don't shuffle between runs.
+func sortedProjects(conf *config.Config) []string {
+	projects := make([]string, 0, len(conf.Projects))
+	for project := range conf.Projects {
+		if project != config.AllProjects {
+			projects = append(projects, project)
+		}
+	}
+	sort.Strings(projects)
+	return projects
+}
+
+// projectUsernames lists the usernames followed in a project, including those under "all".
+func projectUsernames(conf *config.Config, project string) []string {
+	return append(append([]string{}, conf.Projects[project]...), conf.Projects[config.AllProjects]...)
+}
+
+// expandProjects replaces wildcard projects keys, e.g. platform/*, with the paths of the projects in their
+// namespace. A project matched by more than one key follows the usernames of each.
+func expandProjects(source mrs.MergeRequestSource, projects map[string][]string) (map[string][]string, error) {
+	keys := make([]string, 0, len(projects))
+	for key := range projects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	expanded := map[string][]string{}
+	for _, key := range keys {
+		namespace, includeSubgroups, ok := config.ProjectPattern(key)
+		if !ok {
+			expanded[key] = appendMissing(expanded[key], projects[key])
+			continue
+		}
+
+		namespaceProjects, err := source.FetchNamespaceProjects(namespace, includeSubgroups)
+		if err != nil {
+			return nil, err
+		}
+		for _, project := range namespaceProjects {
+			expanded[project.PathWithNamespace] = appendMissing(expanded[project.PathWithNamespace], projects[key])
+		}
+	}
+
+	return expanded, nil
+}
+
+// appendMissing appends the values that aren't already in list.
+func appendMissing(list []string, values []string) []string {
+	for _, value := range values {
+		found := false
+		for _, existing := range list {
+			if existing == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, value)
+		}
+	}
+	return list
+}
+
+// printMergeRequests prints MRs in the chosen output, in sections if --group-by is set.
+func printMergeRequests(w io.Writer, allMrs []*gitlab.MergeRequest, details map[int]*mrs.MergeRequestDetails, columns []string, formatTemplate *template.Template, listFlags flags.ListFlags) error {
+	write := func(sectionMrs []*gitlab.MergeRequest) error {
+		switch {
+		case formatTemplate != nil:
+			return mrs.WriteTemplate(w, formatTemplate, sectionMrs)
+		case listFlags.Display.Output == mrs.OutputTable:
+			return mrs.WriteTable(w, sectionMrs, details, mrs.TableOptions{
+				Columns:  columns,
+				NoHeader: listFlags.Display.NoHeader,
+				Width:    utils.TerminalWidth(),
+			})
+		default:
+			return mrs.WriteMergeRequests(w, listFlags.Display.Output, sectionMrs)
+		}
+	}
+
+	if listFlags.Display.GroupBy == "" {
+		return write(allMrs)
+	}
+
+	groups, err := mrs.GroupMergeRequests(allMrs, listFlags.Display.GroupBy)
+	if err != nil {
+		return err
+	}
+
+	for i, group := range groups {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+
+		header := group.Name
+		if listFlags.Boolean.Count {
+			header = fmt.Sprintf("%s (%d)", group.Name, len(group.MergeRequests))
+		}
+		fmt.Fprintln(w, header)
+
+		if err := write(group.MergeRequests); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // chooseUsernames chooses usernames provided via the user flag over the config.
 func chooseUsernames(flagUsernames []string, configUsernames []string) []string {
 	if len(flagUsernames) != 0 {
@@ -178,12 +408,7 @@ func dedupeMergeRequests(mergeRequests []*gitlab.MergeRequest) []*gitlab.MergeRe
 	return result
 }
 
-func excludeMrsApprovedByMe(glabClient *glab.TGitlabClient, groupId string, me int, draftFlag bool, allMrs []*gitlab.MergeRequest) ([]*gitlab.MergeRequest, error) {
-	approvedMrs, err := mrs.GetMergeRequestsApprovedByMe(glabClient, groupId, me, &draftFlag)
-	if err != nil {
-		return nil, err
-	}
-
+func excludeMrsApprovedByMe(approvedMrs []*gitlab.MergeRequest, allMrs []*gitlab.MergeRequest) []*gitlab.MergeRequest {
 	mrsNotApprovedByMe := []*gitlab.MergeRequest{}
 	for _, mr := range allMrs {
 		isApproved := false
@@ -198,5 +423,5 @@ func excludeMrsApprovedByMe(glabClient *glab.TGitlabClient, groupId string, me i
 		}
 	}
 
-	return mrsNotApprovedByMe, nil
+	return mrsNotApprovedByMe
 }
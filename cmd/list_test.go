@@ -0,0 +1,234 @@
+package cmd
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mjburtenshaw/macglab/config"
+	"github.com/mjburtenshaw/macglab/fakegitlab"
+	"github.com/mjburtenshaw/macglab/flags"
+	"github.com/mjburtenshaw/macglab/mrs"
+	"github.com/xanzy/go-gitlab"
+)
+
+// newFakeSource starts a fake GitLab seeded from testdata and returns a source backed by it.
+func newFakeSource(t *testing.T) mrs.MergeRequestSource {
+	t.Helper()
+	source, _ := newFakeGitlab(t)
+	return source
+}
+
+// newFakeGitlab starts a fake GitLab seeded from testdata and returns it with a source backed by it.
+func newFakeGitlab(t *testing.T) (*mrs.GitlabSource, *fakegitlab.Server) {
+	t.Helper()
+
+	fixtures, err := fakegitlab.LoadFixtures("testdata/merge_requests.json")
+	if err != nil {
+		t.Fatalf("couldn't load fixtures: %v", err)
+	}
+
+	server := fakegitlab.NewServer(fixtures)
+	t.Cleanup(server.Close)
+
+	glabClient, err := server.Client()
+	if err != nil {
+		t.Fatalf("couldn't create client: %v", err)
+	}
+
+	return mrs.NewGitlabSource(glabClient), server
+}
+
+func testConfig() *config.Config {
+	return &config.Config{
+		GroupId: "42",
+		Me:      "7",
+		Projects: map[string][]string{
+			"all": {"erin"},
+			"1":   {"alice"},
+			"2":   {"dave"},
+		},
+		Usernames: []string{"alice", "bob", "mia"},
+	}
+}
+
+func mergeRequestIds(mergeRequests []*gitlab.MergeRequest) []int {
+	ids := []int{}
+	for _, mr := range mergeRequests {
+		ids = append(ids, mr.ID)
+	}
+	return ids
+}
+
+func TestFetchMergeRequests(t *testing.T) {
+	tests := []struct {
+		name      string
+		me        int
+		usernames []string
+		boolean   flags.BooleanFlags
+		perPage   int
+		want      []int
+	}{
+		{
+			name: "excludes drafts, closed, approved by me and mergeable MRs I didn't author",
+			me:   7,
+			want: []int{101, 104, 109, 108, 105},
+		},
+		{
+			name:    "walks every page",
+			me:      7,
+			perPage: 1,
+			want:    []int{101, 104, 109, 108, 105},
+		},
+		{
+			name:    "draft includes drafts",
+			me:      7,
+			boolean: flags.BooleanFlags{Draft: true},
+			want:    []int{101, 102, 104, 109, 108, 105},
+		},
+		{
+			name:    "ready includes mergeable MRs",
+			me:      7,
+			boolean: flags.BooleanFlags{Ready: true},
+			want:    []int{101, 103, 104, 109, 108, 105},
+		},
+		{
+			name:    "approved includes MRs I approved",
+			me:      7,
+			boolean: flags.BooleanFlags{Approved: true},
+			want:    []int{101, 106, 104, 109, 108, 105},
+		},
+		{
+			name:    "group only uses configured usernames",
+			me:      7,
+			boolean: flags.BooleanFlags{Group: true},
+			want:    []int{101, 104, 105},
+		},
+		{
+			name:    "projects only uses project usernames",
+			me:      7,
+			boolean: flags.BooleanFlags{Projects: true},
+			want:    []int{101, 109, 108, 105},
+		},
+		{
+			name:      "users override configured usernames",
+			me:        7,
+			usernames: []string{"carol"},
+			want:      []int{105},
+		},
+		{
+			name: "without me, keeps approved MRs and drops every mergeable MR",
+			me:   0,
+			want: []int{101, 106, 109, 108},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mrs.SetPagination(tt.perPage, 0)
+			t.Cleanup(func() { mrs.SetPagination(0, 0) })
+
+			resolvedFlags := flags.ResolvedFlags{
+				GroupId:     "42",
+				Me:          tt.me,
+				Usernames:   tt.usernames,
+				Concurrency: 3,
+			}
+
+			got, err := fetchMergeRequests(newFakeSource(t), testConfig(), resolvedFlags, tt.boolean)
+			if err != nil {
+				t.Fatalf("fetchMergeRequests() error = %v", err)
+			}
+
+			if ids := mergeRequestIds(got); !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("fetchMergeRequests() = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetchMergeRequestsWithoutMeSkipsReviewerQuery(t *testing.T) {
+	source, server := newFakeGitlab(t)
+
+	resolvedFlags := flags.ResolvedFlags{GroupId: "42", Me: 0, Concurrency: 3}
+	if _, err := fetchMergeRequests(source, testConfig(), resolvedFlags, flags.BooleanFlags{}); err != nil {
+		t.Fatalf("fetchMergeRequests() error = %v", err)
+	}
+
+	for _, request := range server.Requests() {
+		if strings.Contains(request, "reviewer_id") || strings.Contains(request, "approved_by_ids") {
+			t.Errorf("requested %s without knowing who you are", request)
+		}
+	}
+}
+
+func TestDedupeMergeRequests(t *testing.T) {
+	mergeRequests := []*gitlab.MergeRequest{
+		{ID: 1, WebURL: "https://gitlab.example.com/a/-/merge_requests/1"},
+		{ID: 2, WebURL: "https://gitlab.example.com/a/-/merge_requests/2"},
+		{ID: 1, WebURL: "https://gitlab.example.com/a/-/merge_requests/1"},
+	}
+
+	if ids := mergeRequestIds(dedupeMergeRequests(mergeRequests)); !reflect.DeepEqual(ids, []int{1, 2}) {
+		t.Errorf("dedupeMergeRequests() = %v, want [1 2]", ids)
+	}
+}
+
+func TestPrintMergeRequestsGroupBy(t *testing.T) {
+	allMrs := []*gitlab.MergeRequest{
+		{ID: 1, IID: 1, Author: &gitlab.BasicUser{Username: "alice"}, WebURL: "https://gitlab.example.com/acme/web/-/merge_requests/1"},
+		{ID: 2, IID: 2, Author: &gitlab.BasicUser{Username: "bob"}, WebURL: "https://gitlab.example.com/acme/api/-/merge_requests/2"},
+		{ID: 3, IID: 3, Author: &gitlab.BasicUser{Username: "alice"}, WebURL: "https://gitlab.example.com/acme/api/-/merge_requests/3"},
+	}
+
+	listFlags := flags.ListFlags{
+		Boolean: flags.BooleanFlags{Count: true},
+		Display: flags.DisplayFlags{GroupBy: mrs.GroupByProject, Output: mrs.OutputText},
+	}
+
+	var output bytes.Buffer
+	if err := printMergeRequests(&output, allMrs, nil, mrs.DefaultColumns, nil, listFlags); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `acme/api (2)
+@bob: https://gitlab.example.com/acme/api/-/merge_requests/2
+@alice: https://gitlab.example.com/acme/api/-/merge_requests/3
+
+acme/web (1)
+@alice: https://gitlab.example.com/acme/web/-/merge_requests/1
+`
+	if output.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", output.String(), want)
+	}
+}
+
+func TestExpandProjects(t *testing.T) {
+	source := newFakeSource(t)
+
+	got, err := expandProjects(source, map[string][]string{
+		"all":            {"erin"},
+		"1":              {"alice"},
+		"acme/project-2": {"dave"},
+		"acme/*":         {"bob", "dave"},
+	})
+	if err != nil {
+		t.Fatalf("expandProjects() error = %v", err)
+	}
+
+	want := map[string][]string{
+		"all":            {"erin"},
+		"1":              {"alice"},
+		"acme/project-1": {"bob", "dave"},
+		"acme/project-2": {"bob", "dave"},
+		"acme/project-3": {"bob", "dave"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expandProjects() = %v, want %v", got, want)
+	}
+
+	if _, err := expandProjects(source, map[string][]string{"nowhere/*": {"bob"}}); err == nil {
+		t.Errorf("expandProjects() error = nil, want an error for an unknown group")
+	}
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/mjburtenshaw/macglab/config"
+	"github.com/mjburtenshaw/macglab/files"
+	"github.com/mjburtenshaw/macglab/flags"
+	"github.com/mjburtenshaw/macglab/mrs"
+	"github.com/mjburtenshaw/macglab/notify"
+	"github.com/spf13/cobra"
+	"github.com/xanzy/go-gitlab"
+)
+
+func init() {
+	rootCmd.AddCommand(notifyCmd)
+	flags.AddQueryFlags(notifyCmd)
+}
+
+var notifyCmd = &cobra.Command{
+	Use:   "notify",
+	Short: "Announce new merge requests to the configured notifiers",
+	Long: `notify
+
+Runs the same query as list once and announces the queue to the notifiers in your config:
+- joined_queue: an MR is in the queue.
+- review_requested: you're a reviewer of an MR in the queue.
+- mergeable: an MR you authored is ready to merge.
+
+Each notifier announces an event once, so it's safe to run notify on a schedule, e.g. from cron.
+
+To announce MRs as they arrive instead, use watch --notify.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		session, err := newListSession(sessionOptions{})
+		if err != nil {
+			log.Print(err)
+			return
+		}
+
+		announcer, err := newAnnouncer(session.conf)
+		if err != nil {
+			log.Print(err)
+			return
+		}
+
+		allMrs, err := session.fetchMergeRequests()
+		if err != nil {
+			log.Printf("Failed to fetch merge requests: %v", err)
+			return
+		}
+
+		if err := announcer.announce(queueEvents(allMrs, session.listFlags.Resolved.Me)); err != nil {
+			log.Printf("Failed to announce merge requests: %v", err)
+		}
+	},
+}
+
+// announcer sends events to the configured notifiers and remembers what it sent.
+type announcer struct {
+	notifiers []notify.Notifier
+	ledger    *notify.Ledger
+}
+
+func newAnnouncer(conf *config.Config) (*announcer, error) {
+	notifiers, err := notify.New(conf.Notifiers)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	if len(notifiers) == 0 {
+		return nil, errors.New("no notifiers are configured; add some under notifiers in your config")
+	}
+
+	ledger, err := notify.LoadLedger(files.MacglabNotifiedUrl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load notification history: %w", err)
+	}
+
+	return &announcer{notifiers: notifiers, ledger: ledger}, nil
+}
+
+// announce dispatches events and saves what was announced, even if some notifiers failed.
+func (announcer *announcer) announce(events []notify.Event) error {
+	dispatchErr := notify.Dispatch(announcer.notifiers, events, announcer.ledger)
+
+	if err := announcer.ledger.Save(); err != nil {
+		return errors.Join(dispatchErr, err)
+	}
+
+	return dispatchErr
+}
+
+// queueEvents lists the events implied by every MR in the queue of the user with ID me.
+func queueEvents(allMrs []*gitlab.MergeRequest, me int) []notify.Event {
+	var events []notify.Event
+	for _, mr := range allMrs {
+		events = append(events, notify.QueueEvents(mr, me)...)
+	}
+	return events
+}
+
+// changeEvents turns the changes watch saw into events worth announcing to the user with ID me.
+func changeEvents(changes []mrs.Change, me int) []notify.Event {
+	var events []notify.Event
+	for _, change := range changes {
+		switch {
+		case change.Kind == mrs.ChangeAdded:
+			events = append(events, notify.QueueEvents(change.MergeRequest, me)...)
+		case change.Kind == mrs.ChangeMergeable && notify.IsMine(change.MergeRequest, me):
+			events = append(events, notify.Event{Kind: notify.EventMergeable, MergeRequest: change.MergeRequest})
+		}
+	}
+	return events
+}
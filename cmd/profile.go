@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mjburtenshaw/macglab/config"
+	"github.com/mjburtenshaw/macglab/files"
+	"github.com/spf13/cobra"
+)
+
+// profileAddOptions are the keys profile add can set in a new profile.
+var profileAddOptions struct {
+	baseUrl string
+	groupId string
+	me      string
+	users   string
+}
+
+func init() {
+	rootCmd.AddCommand(profileCmd)
+	profileCmd.AddCommand(profileListCmd)
+	profileCmd.AddCommand(profileUseCmd)
+	profileCmd.AddCommand(profileAddCmd)
+	profileCmd.AddCommand(profileRemoveCmd)
+
+	profileAddFlags := profileAddCmd.Flags()
+	profileAddFlags.StringVar(&profileAddOptions.baseUrl, "base-url", "", "The profile's GitLab base URL.")
+	profileAddFlags.StringVarP(&profileAddOptions.groupId, "group-id", "i", "", "The profile's group ID.")
+	profileAddFlags.StringVarP(&profileAddOptions.me, "me", "m", "", "The profile's me username or user ID.")
+	profileAddFlags.StringVarP(&profileAddOptions.users, "users", "u", "", "The profile's usernames. Accepts a CSV of usernames.")
+}
+
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Switch between sets of config, e.g. for work and open source",
+	Long: `profile
+
+Manages named profiles in your config, each with its own access token, group, me, usernames and so on.
+
+The top-level keys of your config are the default profile. A named profile only needs the keys that differ;
+every other key falls back to the top-level value.
+
+Every command uses the current profile, unless you pass --profile <name>.`,
+}
+
+var profileListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List profiles, marking the current one",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		conf, err := config.Read(files.MacglabConfigUrl)
+		if err != nil {
+			log.Printf("Failed to read config: %v", err)
+			return
+		}
+
+		if err := writeProfiles(os.Stdout, conf); err != nil {
+			log.Printf("Failed to print profiles: %v", err)
+		}
+	},
+}
+
+var profileUseCmd = &cobra.Command{
+	Use:   "use <name>",
+	Short: "Make a profile current",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := config.UseProfile(files.MacglabConfigUrl, args[0]); err != nil {
+			log.Printf("Failed to use profile %s: %v", args[0], err)
+			return
+		}
+		fmt.Printf("macglab: using profile %s.\n", args[0])
+	},
+}
+
+var profileAddCmd = &cobra.Command{
+	Use:   "add <name>",
+	Short: "Add a profile",
+	Long: `profile add
+
+Adds a profile. Use the flags to set its keys now, or config set profiles.<name>.<key> <value> later.
+To keep its access token out of your config and shell history, run macglab --profile <name> auth login.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := config.AddProfile(files.MacglabConfigUrl, args[0], profileValues()); err != nil {
+			log.Printf("Failed to add profile %s: %v", args[0], err)
+			return
+		}
+		fmt.Printf("macglab: added profile %s. Run `macglab --profile %s auth login` to store its access token, then `macglab profile use %s` to switch to it.\n", args[0], args[0], args[0])
+	},
+}
+
+var profileRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Remove a profile",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := config.RemoveProfile(files.MacglabConfigUrl, args[0]); err != nil {
+			log.Printf("Failed to remove profile %s: %v", args[0], err)
+			return
+		}
+		fmt.Printf("macglab: removed profile %s.\n", args[0])
+	},
+}
+
+// profileValues returns the keys profile add's flags set.
+func profileValues() map[string]string {
+	values := map[string]string{}
+	if profileAddOptions.baseUrl != "" {
+		values["base_url"] = profileAddOptions.baseUrl
+	}
+	if profileAddOptions.groupId != "" {
+		// Keep group IDs strings, like the sample config.
+		values["group_id"] = strconv.Quote(profileAddOptions.groupId)
+	}
+	if profileAddOptions.me != "" {
+		values["me"] = profileAddOptions.me
+	}
+	if users := strings.ReplaceAll(profileAddOptions.users, " ", ""); users != "" {
+		values["usernames"] = "[" + users + "]"
+	}
+	return values
+}
+
+// writeProfiles prints each profile, marking the current one with an asterisk.
+func writeProfiles(w io.Writer, conf *config.Config) error {
+	current := conf.CurrentProfile
+	if current == "" {
+		current = config.DefaultProfile
+	}
+
+	for _, name := range conf.ProfileNames() {
+		marker := " "
+		if name == current {
+			marker = "*"
+		}
+		if _, err := fmt.Fprintf(w, "%s %s\n", marker, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mjburtenshaw/macglab/files"
+	"github.com/mjburtenshaw/macglab/flags"
+	"github.com/mjburtenshaw/macglab/mrs"
+	"github.com/mjburtenshaw/macglab/snooze"
+	"github.com/mjburtenshaw/macglab/utils"
+	"github.com/spf13/cobra"
+	"github.com/xanzy/go-gitlab"
+)
+
+// Keys of the choices review offers for each MR.
+const (
+	reviewOpen    = 'o'
+	reviewApprove = 'a'
+	reviewComment = 'c'
+	reviewSkip    = 's'
+	reviewSnooze  = 'z'
+	reviewQuit    = 'q'
+)
+
+var reviewChoices = []utils.Choice{
+	{Key: reviewOpen, Label: "open"},
+	{Key: reviewApprove, Label: "approve"},
+	{Key: reviewComment, Label: "comment"},
+	{Key: reviewSkip, Label: "skip"},
+	{Key: reviewSnooze, Label: "snooze"},
+	{Key: reviewQuit, Label: "quit"},
+}
+
+func init() {
+	rootCmd.AddCommand(reviewCmd)
+	flags.AddQueryFlags(reviewCmd)
+}
+
+var reviewCmd = &cobra.Command{
+	Use:   "review",
+	Short: "Review the queue one merge request at a time",
+	Long: `review
+
+Runs the same query as list and walks through the queue, MRs you're a reviewer of first, then oldest first.
+For each MR, it shows the title, author, age, pipeline and size, then asks what to do:
+- o: open the MR in your browser.
+- a: approve the MR, as long as it has no new commits, and move on.
+- c: comment on the MR.
+- s: skip to the next MR.
+- z: snooze the MR until it gets new commits (see snooze).
+- q: quit.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		session, err := newListSession(sessionOptions{forceRefresh: true})
+		if err != nil {
+			log.Print(err)
+			return
+		}
+
+		queue, err := session.fetchMergeRequests()
+		if err != nil {
+			log.Printf("Failed to fetch merge requests: %v", err)
+			return
+		}
+
+		details, err := mrs.FetchDetails(session.source, queue, session.listFlags.Resolved.Concurrency)
+		if err != nil {
+			log.Printf("Failed to fetch merge request details: %v", err)
+			return
+		}
+
+		if err := mrs.SortMergeRequests(queue, mrs.SortByPriority, false, mrs.SortOptions{Me: session.listFlags.Resolved.Me}); err != nil {
+			log.Printf("Failed to sort merge requests: %v", err)
+			return
+		}
+
+		snoozed, err := snooze.Load(files.MacglabSnoozedUrl)
+		if err != nil {
+			log.Printf("Failed to load snoozed merge requests: %v", err)
+			return
+		}
+
+		review := &reviewLoop{
+			actions: session.actions,
+			ask:     utils.AskChoice,
+			askLine: utils.AskQuestion,
+			open:    mrs.OpenMergeRequests,
+			snooze: func(mr *gitlab.MergeRequest) error {
+				snoozed.Snooze(mr, mrs.NewReference(mr).String(), nil, time.Now())
+				return snoozed.Save()
+			},
+			w:       os.Stdout,
+			details: details,
+		}
+		review.run(queue)
+	},
+}
+
+// reviewLoop walks through a queue of MRs, asking what to do with each.
+// Prompting, opening the browser and snoozing are swappable so the loop can be tested.
+type reviewLoop struct {
+	actions mrs.MergeRequestActions
+	ask     func(question string, choices []utils.Choice) rune
+	askLine func(question string) string
+	open    func(mrs []*gitlab.MergeRequest) error
+	snooze  func(mr *gitlab.MergeRequest) error
+	w       io.Writer
+	details map[int]*mrs.MergeRequestDetails
+
+	approved, commented, skipped, snoozed int
+}
+
+// run reviews each MR in order.
+func (review *reviewLoop) run(queue []*gitlab.MergeRequest) {
+	if len(queue) == 0 {
+		fmt.Fprintln(review.w, "macglab: the queue is empty.")
+		return
+	}
+
+	for position, mr := range queue {
+		review.show(mr, position+1, len(queue))
+
+		if review.decide(mr) == reviewQuit {
+			break
+		}
+	}
+
+	review.summarize()
+}
+
+// decide asks what to do with an MR until the answer moves on to another one.
+func (review *reviewLoop) decide(mr *gitlab.MergeRequest) rune {
+	ref := mrs.NewReference(mr)
+
+	for {
+		switch key := review.ask("What next?", reviewChoices); key {
+		case reviewOpen:
+			if err := review.open([]*gitlab.MergeRequest{mr}); err != nil {
+				log.Printf("Failed to open %s: %v", ref, err)
+			}
+		case reviewApprove:
+			if err := review.actions.ApproveMergeRequest(ref, mr.SHA); err != nil {
+				log.Print(err)
+				continue
+			}
+			fmt.Fprintf(review.w, "macglab: approved %s.\n", ref)
+			review.approved++
+			return key
+		case reviewComment:
+			body := review.askLine("Comment (leave empty to cancel): ")
+			if body == "" {
+				continue
+			}
+			if err := review.actions.CommentOnMergeRequest(ref, body); err != nil {
+				log.Print(err)
+				continue
+			}
+			fmt.Fprintf(review.w, "macglab: commented on %s.\n", ref)
+			review.commented++
+		case reviewSkip:
+			review.skipped++
+			return key
+		case reviewSnooze:
+			if err := review.snooze(mr); err != nil {
+				log.Printf("Failed to snooze %s: %v", ref, err)
+				continue
+			}
+			fmt.Fprintf(review.w, "macglab: snoozed %s until its next push.\n", ref)
+			review.snoozed++
+			return key
+		default:
+			return key
+		}
+	}
+}
+
+// show prints what's worth knowing about an MR before deciding what to do with it.
+func (review *reviewLoop) show(mr *gitlab.MergeRequest, position int, total int) {
+	facts := []string{authorLabel(mr), "opened " + mrs.RelativeTime(mr.CreatedAt) + " ago"}
+	if mrDetails, ok := review.details[mr.ID]; ok {
+		if mrDetails.PipelineStatus != "" {
+			facts = append(facts, "pipeline "+mrDetails.PipelineStatus)
+		}
+		if mrDetails.ChangesCount != "" {
+			facts = append(facts, mrDetails.ChangesCount+" files changed")
+		}
+		if mrDetails.ApprovalsRequired > 0 {
+			facts = append(facts, fmt.Sprintf("%d/%d approvals", mrDetails.Approvals, mrDetails.ApprovalsRequired))
+		}
+	}
+
+	fmt.Fprintf(review.w, "\n[%d/%d] %s %s\n", position, total, mrs.NewReference(mr), mr.Title)
+	fmt.Fprintf(review.w, "  %s\n", strings.Join(facts, " · "))
+	fmt.Fprintf(review.w, "  %s\n", mr.WebURL)
+}
+
+func (review *reviewLoop) summarize() {
+	fmt.Fprintf(review.w, "\nmacglab: %d approved, %d commented on, %d skipped, %d snoozed.\n",
+		review.approved, review.commented, review.skipped, review.snoozed)
+}
+
+func authorLabel(mr *gitlab.MergeRequest) string {
+	if mr.Author == nil {
+		return "(no author)"
+	}
+	return "@" + mr.Author.Username
+}
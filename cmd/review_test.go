@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mjburtenshaw/macglab/fakegitlab"
+	"github.com/mjburtenshaw/macglab/mrs"
+	"github.com/mjburtenshaw/macglab/utils"
+	"github.com/xanzy/go-gitlab"
+)
+
+func TestReviewLoop(t *testing.T) {
+	source, server := newFakeGitlab(t)
+
+	var queue []*gitlab.MergeRequest
+	for _, id := range []int{101, 109, 108} {
+		fixture := fixtureById(t, server, id)
+		queue = append(queue, &fixture.MergeRequest)
+	}
+
+	keys := []rune{
+		reviewComment, reviewApprove, // 101
+		reviewSnooze,           // 109
+		reviewOpen, reviewSkip, // 108
+	}
+	var opened []string
+	var snoozed []int
+	var output bytes.Buffer
+
+	review := &reviewLoop{
+		actions: source,
+		ask: func(question string, choices []utils.Choice) rune {
+			if len(keys) == 0 {
+				t.Fatal("review asked for more keys than expected")
+			}
+			key := keys[0]
+			keys = keys[1:]
+			return key
+		},
+		askLine: func(question string) string { return "Nit: rename this." },
+		open: func(mergeRequests []*gitlab.MergeRequest) error {
+			opened = append(opened, mergeRequests[0].WebURL)
+			return nil
+		},
+		snooze: func(mr *gitlab.MergeRequest) error {
+			snoozed = append(snoozed, mr.ID)
+			return nil
+		},
+		w:       &output,
+		details: map[int]*mrs.MergeRequestDetails{101: {PipelineStatus: "success", ChangesCount: "3", Approvals: 0, ApprovalsRequired: 1}},
+	}
+	review.run(queue)
+
+	if len(keys) != 0 {
+		t.Errorf("review stopped with keys %q left", string(keys))
+	}
+
+	wantPositions := []string{"[1/3] acme/project-1!1", "[2/3] acme/project-1!3", "[3/3] acme/project-2!3"}
+	for _, position := range wantPositions {
+		if !strings.Contains(output.String(), position) {
+			t.Errorf("output doesn't show %q:\n%s", position, output.String())
+		}
+	}
+	if !strings.Contains(output.String(), "@alice · opened") || !strings.Contains(output.String(), "pipeline success · 3 files changed · 0/1 approvals") {
+		t.Errorf("output doesn't describe MR 101:\n%s", output.String())
+	}
+	if !strings.HasSuffix(output.String(), "macglab: 1 approved, 1 commented on, 1 skipped, 1 snoozed.\n") {
+		t.Errorf("output doesn't end with a summary:\n%s", output.String())
+	}
+
+	if want := []string{"https://gitlab.example.com/acme/project-2/-/merge_requests/3"}; !reflect.DeepEqual(opened, want) {
+		t.Errorf("opened %v, want %v", opened, want)
+	}
+	if want := []int{109}; !reflect.DeepEqual(snoozed, want) {
+		t.Errorf("snoozed %v, want %v", snoozed, want)
+	}
+
+	fixture := fixtureById(t, server, 101)
+	if !reflect.DeepEqual(fixture.ApprovedByIds, []int{fakegitlab.DefaultUserId}) {
+		t.Errorf("MR 101 approved by %v, want [%d]", fixture.ApprovedByIds, fakegitlab.DefaultUserId)
+	}
+	if len(fixture.Notes) != 2 || fixture.Notes[0].Body != "Nit: rename this." {
+		t.Errorf("MR 101 notes = %+v, want the comment then the approval", fixture.Notes)
+	}
+}
+
+func TestReviewLoopQuit(t *testing.T) {
+	var output bytes.Buffer
+	review := &reviewLoop{
+		ask: func(question string, choices []utils.Choice) rune { return reviewQuit },
+		w:   &output,
+	}
+	review.run([]*gitlab.MergeRequest{
+		{ID: 1, IID: 1, WebURL: "https://gitlab.example.com/acme/web/-/merge_requests/1"},
+		{ID: 2, IID: 2, WebURL: "https://gitlab.example.com/acme/web/-/merge_requests/2"},
+	})
+
+	if strings.Contains(output.String(), "[2/2]") {
+		t.Errorf("review kept going after quitting:\n%s", output.String())
+	}
+}
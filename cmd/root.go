@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 
+	"github.com/mjburtenshaw/macglab/flags"
 	"github.com/spf13/cobra"
 )
 
@@ -27,6 +28,10 @@ var rootCmd = &cobra.Command{
 	},
 }
 
+func init() {
+	flags.AddGlobalFlags(rootCmd)
+}
+
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
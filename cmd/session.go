@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/mjburtenshaw/macglab/auth"
+	"github.com/mjburtenshaw/macglab/config"
+	"github.com/mjburtenshaw/macglab/files"
+	"github.com/mjburtenshaw/macglab/flags"
+	"github.com/mjburtenshaw/macglab/glab"
+	"github.com/mjburtenshaw/macglab/mrs"
+	"github.com/mjburtenshaw/macglab/snooze"
+	"github.com/mjburtenshaw/macglab/users"
+	"github.com/xanzy/go-gitlab"
+)
+
+// listSession holds what list, and the commands built on its query, need to fetch MRs.
+type listSession struct {
+	conf      *config.Config
+	listFlags flags.ListFlags
+	source    mrs.MergeRequestSource
+	actions   mrs.MergeRequestActions
+	// expanded is set once wildcard projects keys have been replaced with the projects they match.
+	expanded bool
+}
+
+// sessionOptions change how newListSession connects to GitLab.
+type sessionOptions struct {
+	// conf is the config, if the command already read it.
+	conf *config.Config
+	// forceRefresh revalidates cached responses regardless of --refresh, for commands that poll,
+	// change MRs or remember their current state.
+	forceRefresh bool
+	// skipMe doesn't work out who you are, for commands that never ask.
+	skipMe bool
+}
+
+// newListSession reads the config and list flags and connects to GitLab.
+func newListSession(options sessionOptions) (*listSession, error) {
+	conf := options.conf
+	if conf == nil {
+		var err error
+		conf, err = readConfig()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config: %w", err)
+		}
+	}
+
+	listFlags := flags.GetListFlags(conf)
+
+	mrs.SetPagination(conf.PerPage, conf.MaxPages)
+
+	cacheTTL, err := conf.GetCacheTTL()
+	if err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	cacheDir := files.MacglabCacheUri
+	if listFlags.Boolean.NoCache {
+		cacheDir = ""
+	}
+
+	accessToken, err := auth.AccessToken(listFlags.Resolved.AccessToken, auth.Sources{
+		Command: conf.AccessTokenCommand,
+		Account: conf.Profile,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get access token: %w", err)
+	}
+
+	glabClient, err := glab.Initialize(accessToken, glab.ClientOptions{
+		BaseUrl:            listFlags.Resolved.BaseUrl,
+		CaFile:             conf.CaFile,
+		InsecureSkipVerify: conf.InsecureSkipVerify,
+		CacheDir:           cacheDir,
+		CacheTTL:           cacheTTL,
+		RefreshCache:       listFlags.Boolean.Refresh || options.forceRefresh,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize gitlab client: %w", err)
+	}
+
+	userCache, err := users.Load(files.MacglabUsersUrl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read user cache: %w", err)
+	}
+	source := mrs.NewGitlabSource(glabClient).WithUserCache(userCache)
+
+	if !options.skipMe {
+		listFlags.Resolved.Me, err = resolveMe(source, listFlags.Resolved.MeUser)
+		if err != nil {
+			return nil, err
+		}
+		if err := userCache.Save(); err != nil {
+			log.Printf("Failed to save user cache: %v", err)
+		}
+	}
+
+	return &listSession{
+		conf:      conf,
+		listFlags: listFlags,
+		source:    source,
+		actions:   source,
+	}, nil
+}
+
+// resolveMe finds your user ID from me, a username or ID. When me isn't set, it asks GitLab who the
+// access token belongs to; if that fails, commands carry on without knowing who you are.
+func resolveMe(source mrs.MergeRequestSource, me string) (int, error) {
+	id, err := source.ResolveUser(me)
+	if err != nil && me == "" {
+		log.Printf("Failed to detect me: %v", err)
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to resolve me: %w", err)
+	}
+	return id, nil
+}
+
+// readConfig reads the config for the --profile profile, or the current one.
+func readConfig() (*config.Config, error) {
+	return config.ReadProfile(files.MacglabConfigUrl, flags.GetGlobalFlags().Profile)
+}
+
+// fetchMergeRequests runs list's query, then hides snoozed and ignored MRs unless --show-snoozed is set.
+func (session *listSession) fetchMergeRequests() ([]*gitlab.MergeRequest, error) {
+	if err := session.expandProjects(); err != nil {
+		return nil, err
+	}
+
+	queue, err := fetchMergeRequests(session.source, session.conf, session.listFlags.Resolved, session.listFlags.Boolean)
+	if err != nil {
+		return nil, err
+	}
+
+	if session.listFlags.Boolean.ShowSnoozed {
+		return queue, nil
+	}
+
+	return hideSnoozed(queue, files.MacglabSnoozedUrl, time.Now())
+}
+
+// expandProjects expands wildcard projects keys in the config the first time a query needs them, so
+// commands that don't search projects never list the groups behind them.
+func (session *listSession) expandProjects() error {
+	if session.expanded {
+		return nil
+	}
+
+	projects, err := expandProjects(session.source, session.conf.Projects)
+	if err != nil {
+		return fmt.Errorf("failed to expand projects: %w", err)
+	}
+
+	session.conf.Projects, session.expanded = projects, true
+	return nil
+}
+
+// hideSnoozed drops snoozed and ignored MRs from the queue. It saves the snooze list if any
+// snoozes ended, so MRs with new commits stay unsnoozed.
+func hideSnoozed(queue []*gitlab.MergeRequest, snoozedUrl string, now time.Time) ([]*gitlab.MergeRequest, error) {
+	snoozed, err := snooze.Load(snoozedUrl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load snoozed merge requests: %w", err)
+	}
+
+	visible, _, changed := snoozed.Filter(queue, now)
+	if changed {
+		if err := snoozed.Save(); err != nil {
+			return nil, fmt.Errorf("failed to save snoozed merge requests: %w", err)
+		}
+	}
+
+	return visible, nil
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mjburtenshaw/macglab/config"
+	"github.com/mjburtenshaw/macglab/users"
+)
+
+func TestResolveMe(t *testing.T) {
+	cacheUrl := filepath.Join(t.TempDir(), "users.json")
+	userCache, err := users.Load(cacheUrl)
+	if err != nil {
+		t.Fatalf("users.Load() error = %v", err)
+	}
+	gitlabSource, server := newFakeGitlab(t)
+	source := gitlabSource.WithUserCache(userCache)
+
+	tests := []struct {
+		name      string
+		me        string
+		want      int
+		wantError string
+	}{
+		{name: "detected from the access token", me: "", want: 7},
+		{name: "user ID", me: "12", want: 12},
+		{name: "username", me: "bob", want: 3},
+		{name: "username with an at sign", me: "@bob", want: 3},
+		{name: "username in another case", me: "BOB", want: 3},
+		{name: "unknown username", me: "nobody", wantError: "there's no GitLab user named nobody"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveMe(source, tt.me)
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Fatalf("resolveMe() error = %v, want %q", err, tt.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveMe() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("resolveMe() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	lookups := 0
+	for _, request := range server.Requests() {
+		if strings.HasPrefix(request, "/api/v4/users?") && strings.Contains(strings.ToLower(request), "username=bob") {
+			lookups++
+		}
+	}
+	if lookups != 1 {
+		t.Errorf("looked up bob %d times, want once thanks to the user cache", lookups)
+	}
+
+	if err := userCache.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	reloaded, err := users.Load(cacheUrl)
+	if err != nil {
+		t.Fatalf("users.Load() error = %v", err)
+	}
+	instance := server.URL + "/api/v4/"
+	for username, want := range map[string]int{"bob": 3, "mia": 7} {
+		if id, ok := reloaded.Id(instance, username); !ok || id != want {
+			t.Errorf("cached %s = %d, %v, want %d", username, id, ok, want)
+		}
+	}
+}
+
+func TestListSessionExpandsProjectsOnce(t *testing.T) {
+	source, server := newFakeGitlab(t)
+	session := &listSession{
+		conf:   &config.Config{Projects: map[string][]string{"acme/*": {"bob"}}},
+		source: source,
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := session.expandProjects(); err != nil {
+			t.Fatalf("expandProjects() error = %v", err)
+		}
+	}
+
+	if _, ok := session.conf.Projects["acme/project-1"]; !ok {
+		t.Errorf("projects = %v, want acme/* expanded", session.conf.Projects)
+	}
+
+	lookups := 0
+	for _, request := range server.Requests() {
+		if strings.HasPrefix(request, "/api/v4/groups/") {
+			lookups++
+		}
+	}
+	if lookups != 1 {
+		t.Errorf("listed the group %d times, want once per session", lookups)
+	}
+}
@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mjburtenshaw/macglab/files"
+	"github.com/mjburtenshaw/macglab/mrs"
+	"github.com/mjburtenshaw/macglab/snooze"
+	"github.com/mjburtenshaw/macglab/utils"
+	"github.com/spf13/cobra"
+	"github.com/xanzy/go-gitlab"
+)
+
+// untilNextPush is the --until value that snoozes an MR until it gets new commits.
+const untilNextPush = "next-push"
+
+var snoozeUntil string
+
+func init() {
+	rootCmd.AddCommand(snoozeCmd)
+	rootCmd.AddCommand(ignoreCmd)
+	rootCmd.AddCommand(unsnoozeCmd)
+
+	snoozeCmd.Flags().StringVar(&snoozeUntil, "until", untilNextPush, "Snooze until a duration from now, e.g. 2d, a date, e.g. 2024-01-31, or next-push.")
+}
+
+var snoozeCmd = &cobra.Command{
+	Use:   "snooze [<project>!<iid>|<url>...]",
+	Short: "Hide merge requests from the queue for a while",
+	Long: `snooze
+
+Hides merge requests, given as <project>!<iid>, e.g. acme/web!42, or as URLs, from list and the commands built on its query.
+
+By default, an MR stays snoozed until it gets new commits. Use --until to also end the snooze after a duration, e.g. 2d,
+or on a date, e.g. 2024-01-31. New commits always end a snooze, so you never miss changes to an MR.
+
+Without merge requests, snooze prints what's snoozed and ignored. Use --show-snoozed with list to include them anyway.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		now := time.Now()
+		until, err := parseSnoozeUntil(snoozeUntil, now)
+		if err != nil {
+			log.Printf("Invalid flag: %v", err)
+			return
+		}
+
+		if len(args) == 0 {
+			snoozed, err := snooze.Load(files.MacglabSnoozedUrl)
+			if err != nil {
+				log.Printf("Failed to load snoozed merge requests: %v", err)
+				return
+			}
+			if err := writeSnoozes(os.Stdout, snoozed.Entries()); err != nil {
+				log.Printf("Failed to print snoozed merge requests: %v", err)
+			}
+			return
+		}
+
+		runSnoozeChanges(args, func(snoozed *snooze.List, mr *gitlab.MergeRequest, ref mrs.Reference) string {
+			entry := snoozed.Snooze(mr, ref.String(), until, now)
+			return fmt.Sprintf("snoozed %s %s", ref, entry.Describe())
+		})
+	},
+}
+
+var ignoreCmd = &cobra.Command{
+	Use:   "ignore <project>!<iid>|<url>...",
+	Short: "Hide merge requests from the queue for good",
+	Long: `ignore
+
+Hides merge requests, given as <project>!<iid>, e.g. acme/web!42, or as URLs, from list and the commands built on its query,
+even after they get new commits. Use unsnooze to show them again.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		now := time.Now()
+		runSnoozeChanges(args, func(snoozed *snooze.List, mr *gitlab.MergeRequest, ref mrs.Reference) string {
+			snoozed.Ignore(mr, ref.String(), now)
+			return fmt.Sprintf("ignored %s", ref)
+		})
+	},
+}
+
+var unsnoozeCmd = &cobra.Command{
+	Use:   "unsnooze <project>!<iid>|<url>...",
+	Short: "Show snoozed or ignored merge requests in the queue again",
+	Long: `unsnooze
+
+Shows merge requests, given as <project>!<iid>, e.g. acme/web!42, or as URLs, that you snoozed or ignored in the queue again.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runSnoozeChanges(args, func(snoozed *snooze.List, mr *gitlab.MergeRequest, ref mrs.Reference) string {
+			if !snoozed.Unsnooze(mr) {
+				return fmt.Sprintf("%s wasn't snoozed", ref)
+			}
+			return fmt.Sprintf("unsnoozed %s", ref)
+		})
+	},
+}
+
+// runSnoozeChanges connects to GitLab and changes the snooze list for each MR given as an argument.
+func runSnoozeChanges(args []string, change snoozeChange) {
+	session, err := newListSession(sessionOptions{forceRefresh: true})
+	if err != nil {
+		log.Print(err)
+		return
+	}
+
+	if err := changeSnoozes(session.source, files.MacglabSnoozedUrl, args, os.Stdout, change); err != nil {
+		log.Print(err)
+	}
+}
+
+// snoozeChange changes an MR's entry in the snooze list and describes what it did, e.g. `ignored acme/web!42`.
+type snoozeChange func(snoozed *snooze.List, mr *gitlab.MergeRequest, ref mrs.Reference) string
+
+// changeSnoozes fetches each MR given as an argument, changes its entry in the snooze list and saves the list.
+// MRs are fetched so a snooze knows the head commit it ends after, and so URLs and references to the same MR agree.
+func changeSnoozes(source mrs.MergeRequestSource, snoozedUrl string, args []string, w io.Writer, change snoozeChange) error {
+	var refs []mrs.Reference
+	for _, arg := range args {
+		ref, err := mrs.ParseReference(arg)
+		if err != nil {
+			return fmt.Errorf("invalid argument: %w", err)
+		}
+		refs = append(refs, ref)
+	}
+
+	snoozed, err := snooze.Load(snoozedUrl)
+	if err != nil {
+		return fmt.Errorf("failed to load snoozed merge requests: %w", err)
+	}
+
+	var messages []string
+	for _, ref := range refs {
+		mr, err := source.FetchMergeRequest(ref)
+		if err != nil {
+			return fmt.Errorf("failed to fetch merge request: %w", err)
+		}
+		messages = append(messages, change(snoozed, mr, mrs.NewReference(mr)))
+	}
+
+	if err := snoozed.Save(); err != nil {
+		return fmt.Errorf("failed to save snoozed merge requests: %w", err)
+	}
+
+	for _, message := range messages {
+		fmt.Fprintf(w, "macglab: %s.\n", message)
+	}
+
+	return nil
+}
+
+// parseSnoozeUntil parses --until as a duration from now or a YYYY-MM-DD date, in local time.
+// next-push returns nil, since the snooze only ends with new commits.
+func parseSnoozeUntil(value string, now time.Time) (*time.Time, error) {
+	if value == untilNextPush {
+		return nil, nil
+	}
+
+	if duration, err := utils.ParseDuration(value); err == nil {
+		if duration <= 0 {
+			return nil, fmt.Errorf("--until must be in the future, got %q", value)
+		}
+		until := now.Add(duration)
+		return &until, nil
+	}
+
+	until, err := time.ParseInLocation("2006-01-02", value, now.Location())
+	if err != nil {
+		return nil, fmt.Errorf("--until must be a duration, e.g. 2d, a date, e.g. 2024-01-31, or %s, got %q", untilNextPush, value)
+	}
+	if !until.After(now) {
+		return nil, fmt.Errorf("--until must be in the future, got %q", value)
+	}
+
+	return &until, nil
+}
+
+// writeSnoozes prints snoozed and ignored MRs, one per line.
+func writeSnoozes(w io.Writer, entries []snooze.Entry) error {
+	if len(entries) == 0 {
+		_, err := fmt.Fprintln(w, "macglab: nothing is snoozed or ignored.")
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, entry := range entries {
+		fmt.Fprintf(tw, "%s\t%s\tsince %s\n", entry.Reference, entry.Describe(), entry.SnoozedAt.Local().Format("Mon Jan 2 15:04"))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mjburtenshaw/macglab/mrs"
+	"github.com/mjburtenshaw/macglab/snooze"
+	"github.com/xanzy/go-gitlab"
+)
+
+func TestSnoozeHidesMergeRequestsUntilNextPush(t *testing.T) {
+	source, server := newFakeGitlab(t)
+	snoozedUrl := filepath.Join(t.TempDir(), "snoozed.json")
+	now := time.Date(2023, 9, 12, 12, 0, 0, 0, time.UTC)
+
+	var output bytes.Buffer
+	err := changeSnoozes(source, snoozedUrl, []string{"1!1", "https://gitlab.example.com/acme/project-1/-/merge_requests/3"}, &output,
+		func(snoozed *snooze.List, mr *gitlab.MergeRequest, ref mrs.Reference) string {
+			entry := snoozed.Snooze(mr, ref.String(), nil, now)
+			return "snoozed " + ref.String() + " " + entry.Describe()
+		})
+	if err != nil {
+		t.Fatalf("changeSnoozes() error = %v", err)
+	}
+
+	wantOutput := "macglab: snoozed acme/project-1!1 until next push.\nmacglab: snoozed acme/project-1!3 until next push.\n"
+	if output.String() != wantOutput {
+		t.Errorf("output = %q, want %q", output.String(), wantOutput)
+	}
+
+	var queue []*gitlab.MergeRequest
+	for _, id := range []int{101, 108, 109} {
+		fixture := fixtureById(t, server, id)
+		queue = append(queue, &fixture.MergeRequest)
+	}
+
+	visible, err := hideSnoozed(queue, snoozedUrl, now)
+	if err != nil {
+		t.Fatalf("hideSnoozed() error = %v", err)
+	}
+	if ids := mergeRequestIds(visible); !reflect.DeepEqual(ids, []int{108}) {
+		t.Errorf("hideSnoozed() = %v, want [108]", ids)
+	}
+
+	// A push to 101 ends its snooze for good.
+	queue[0].SHA = "d4e5f6"
+	visible, err = hideSnoozed(queue, snoozedUrl, now)
+	if err != nil {
+		t.Fatalf("hideSnoozed() error = %v", err)
+	}
+	if ids := mergeRequestIds(visible); !reflect.DeepEqual(ids, []int{101, 108}) {
+		t.Errorf("hideSnoozed() after a push = %v, want [101 108]", ids)
+	}
+
+	snoozed, err := snooze.Load(snoozedUrl)
+	if err != nil {
+		t.Fatalf("snooze.Load() error = %v", err)
+	}
+	if entries := snoozed.Entries(); len(entries) != 1 || entries[0].Reference != "acme/project-1!3" {
+		t.Errorf("entries after a push = %+v, want only acme/project-1!3", entries)
+	}
+}
+
+func TestChangeSnoozesRejectsBadReferences(t *testing.T) {
+	source, _ := newFakeGitlab(t)
+	snoozedUrl := filepath.Join(t.TempDir(), "snoozed.json")
+
+	for _, args := range [][]string{{"acme/project-1"}, {"acme/project-1!99"}} {
+		err := changeSnoozes(source, snoozedUrl, args, &bytes.Buffer{}, func(snoozed *snooze.List, mr *gitlab.MergeRequest, ref mrs.Reference) string {
+			t.Fatalf("changed the snooze of %s", ref)
+			return ""
+		})
+		if err == nil {
+			t.Errorf("changeSnoozes(%q) error = nil, want an error", args)
+		}
+	}
+}
+
+func TestParseSnoozeUntil(t *testing.T) {
+	now := time.Date(2023, 9, 4, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value   string
+		want    *time.Time
+		wantErr bool
+	}{
+		{value: "next-push", want: nil},
+		{value: "2d", want: timePointer(time.Date(2023, 9, 6, 10, 0, 0, 0, time.UTC))},
+		{value: "2023-09-10", want: timePointer(time.Date(2023, 9, 10, 0, 0, 0, 0, time.UTC))},
+		{value: "2023-09-01", wantErr: true},
+		{value: "0s", wantErr: true},
+		{value: "soon", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseSnoozeUntil(tt.value, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSnoozeUntil() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseSnoozeUntil() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func timePointer(t time.Time) *time.Time {
+	return &t
+}
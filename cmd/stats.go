@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"log"
+	"os"
+	"time"
+
+	"github.com/mjburtenshaw/macglab/config"
+	"github.com/mjburtenshaw/macglab/flags"
+	"github.com/mjburtenshaw/macglab/mrs"
+	"github.com/spf13/cobra"
+	"github.com/xanzy/go-gitlab"
+)
+
+var (
+	statsSince  string
+	statsUntil  string
+	statsOutput string
+)
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+	flags.AddQueryFlags(statsCmd)
+	statsCmd.Flags().StringVar(&statsSince, "since", "30d", "Measure MRs created since this long ago, e.g. 2w, or since a date like 2023-09-01.")
+	statsCmd.Flags().StringVar(&statsUntil, "until", "", "Measure MRs created before this long ago, or before a date like 2023-10-01. Defaults to now.")
+	statsCmd.Flags().StringVarP(&statsOutput, "output", "o", mrs.OutputTable, "Output format: table, json or csv.")
+}
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Measure review throughput",
+	Long: `stats
+
+Measures how MRs by the usernames you follow were reviewed, for MRs created between --since and --until:
+- time to first review: from creation to the first comment or approval by someone other than the author.
+- time to approval: from creation to the first approval.
+- time to merge: from creation to merge.
+- MRs per author, and MRs per reviewer assigned and approved.
+
+Durations are summarized by their mean and 50th, 75th and 90th percentiles.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := mrs.ValidateStatsOutputFormat(statsOutput); err != nil {
+			log.Printf("Invalid flag: %v", err)
+			return
+		}
+
+		now := time.Now()
+		since, err := parsePastTime("--since", statsSince, now)
+		if err != nil {
+			log.Printf("Invalid flag: %v", err)
+			return
+		}
+		until := now
+		if statsUntil != "" {
+			until, err = parsePastTime("--until", statsUntil, now)
+			if err != nil {
+				log.Printf("Invalid flag: %v", err)
+				return
+			}
+		}
+		if !since.Before(until) {
+			log.Printf("Invalid flag: --since must be before --until")
+			return
+		}
+
+		session, err := newListSession(sessionOptions{})
+		if err != nil {
+			log.Print(err)
+			return
+		}
+
+		if err := session.expandProjects(); err != nil {
+			log.Printf("Failed to fetch merge requests: %v", err)
+			return
+		}
+
+		concurrency := session.listFlags.Resolved.Concurrency
+		createdMrs, err := fetchMergeRequestsCreatedBetween(session.source, session.conf, session.listFlags.Resolved, session.listFlags.Boolean, since, until)
+		if err != nil {
+			log.Printf("Failed to fetch merge requests: %v", err)
+			return
+		}
+
+		activities, err := mrs.FetchReviewActivities(session.source, createdMrs, concurrency)
+		if err != nil {
+			log.Printf("Failed to fetch review activity: %v", err)
+			return
+		}
+
+		stats := mrs.ComputeStats(createdMrs, activities, since, until)
+		if err := mrs.WriteStats(os.Stdout, statsOutput, stats); err != nil {
+			log.Printf("Failed to write stats: %v", err)
+		}
+	},
+}
+
+// fetchMergeRequestsCreatedBetween fetches MRs in any state by the usernames list follows
+// that were created in [since, until).
+func fetchMergeRequestsCreatedBetween(source mrs.MergeRequestSource, conf *config.Config, resolvedFlags flags.ResolvedFlags, booleanFlags flags.BooleanFlags, since time.Time, until time.Time) ([]*gitlab.MergeRequest, error) {
+	var fetches []mrs.Fetch
+
+	if (!booleanFlags.Group && !booleanFlags.Projects) || booleanFlags.Group {
+		for _, username := range chooseUsernames(resolvedFlags.Usernames, conf.Usernames) {
+			username := username
+			fetches = append(fetches, func() ([]*gitlab.MergeRequest, error) {
+				return source.FetchGroupMergeRequestsCreatedBetween(resolvedFlags.GroupId, username, since, until)
+			})
+		}
+	}
+
+	if (!booleanFlags.Group && !booleanFlags.Projects) || booleanFlags.Projects {
+		for _, project := range sortedProjects(conf) {
+			project := project
+			for _, username := range chooseUsernames(resolvedFlags.Usernames, projectUsernames(conf, project)) {
+				username := username
+				fetches = append(fetches, func() ([]*gitlab.MergeRequest, error) {
+					return source.FetchProjectMergeRequestsCreatedBetween(project, username, since, until)
+				})
+			}
+		}
+	}
+
+	results, err := mrs.FetchConcurrently(fetches, resolvedFlags.Concurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	var createdMrs []*gitlab.MergeRequest
+	for _, result := range results {
+		createdMrs = append(createdMrs, result...)
+	}
+
+	return dedupeMergeRequests(createdMrs), nil
+}
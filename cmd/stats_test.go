@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mjburtenshaw/macglab/flags"
+	"github.com/mjburtenshaw/macglab/mrs"
+)
+
+func TestStats(t *testing.T) {
+	since := time.Date(2023, 9, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)
+	resolvedFlags := flags.ResolvedFlags{GroupId: "42", Me: 7, Concurrency: 2}
+	source := newFakeSource(t)
+
+	createdMrs, err := fetchMergeRequestsCreatedBetween(source, testConfig(), resolvedFlags, flags.BooleanFlags{}, since, until)
+	if err != nil {
+		t.Fatalf("fetchMergeRequestsCreatedBetween() error = %v", err)
+	}
+
+	wantIds := []int{101, 102, 106, 110, 103, 104, 109, 108, 111}
+	if ids := mergeRequestIds(createdMrs); !reflect.DeepEqual(ids, wantIds) {
+		t.Errorf("fetchMergeRequestsCreatedBetween() = %v, want %v", ids, wantIds)
+	}
+
+	activities, err := mrs.FetchReviewActivities(source, createdMrs, 2)
+	if err != nil {
+		t.Fatalf("FetchReviewActivities() error = %v", err)
+	}
+
+	got := mrs.ComputeStats(createdMrs, activities, since, until)
+	want := mrs.Stats{
+		SchemaVersion:     mrs.SchemaVersion,
+		Since:             since,
+		Until:             until,
+		MergeRequests:     9,
+		Merged:            1,
+		TimeToFirstReview: mrs.DurationStats{Count: 2, MeanHours: 14, P50Hours: 4, P75Hours: 24, P90Hours: 24},
+		TimeToApproval:    mrs.DurationStats{Count: 2, MeanHours: 36, P50Hours: 24, P75Hours: 48, P90Hours: 48},
+		TimeToMerge:       mrs.DurationStats{Count: 1, MeanHours: 167, P50Hours: 167, P75Hours: 167, P90Hours: 167},
+		Authors: []mrs.AuthorStats{
+			{Username: "alice", MergeRequests: 4},
+			{Username: "dave", MergeRequests: 2, Merged: 1},
+			{Username: "bob", MergeRequests: 1},
+			{Username: "erin", MergeRequests: 1},
+			{Username: "mia", MergeRequests: 1},
+		},
+		Reviewers: []mrs.ReviewerStats{
+			{Username: "alice", Assigned: 1, Approved: 1},
+			{Username: "mia", Assigned: 1, Approved: 1},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ComputeStats() =\n%+v\nwant\n%+v", got, want)
+	}
+}
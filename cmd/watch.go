@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/mjburtenshaw/macglab/flags"
+	"github.com/mjburtenshaw/macglab/mrs"
+	"github.com/spf13/cobra"
+)
+
+var (
+	watchInterval time.Duration
+	watchNotify   bool
+)
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+	flags.AddQueryFlags(watchCmd)
+	watchCmd.Flags().DurationVar(&watchInterval, "interval", time.Minute, "How long to wait between checks, e.g. 30s or 5m.")
+	watchCmd.Flags().BoolVar(&watchNotify, "notify", false, "Announce changes to the notifiers in your config.")
+}
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Watch merge requests for changes",
+	Long: `watch
+
+Runs the same query as list on an interval and prints what changed:
+- + an MR joined the queue.
+- - an MR left the queue.
+- ~ new commits were pushed to an MR, its pipeline status changed, or it became ready to merge.
+
+With --notify, changes are also announced to the notifiers in your config. See notify for the events.
+
+watch accepts every list flag that filters MRs. It runs until you press Ctrl+C; press it again to stop in the middle of a check.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if watchInterval <= 0 {
+			log.Printf("Invalid flag: --interval must be positive")
+			return
+		}
+
+		// Always revalidate cached responses so changes show up on the next check.
+		session, err := newListSession(sessionOptions{forceRefresh: true})
+		if err != nil {
+			log.Print(err)
+			return
+		}
+
+		var notifications *announcer
+		if watchNotify {
+			notifications, err = newAnnouncer(session.conf)
+			if err != nil {
+				log.Print(err)
+				return
+			}
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		// Checks don't see ctx, so stop catching signals after the first: a second Ctrl+C quits
+		// in the middle of a check instead of waiting for it to finish.
+		go func() {
+			<-ctx.Done()
+			stop()
+		}()
+
+		if err := watch(ctx, session, watchInterval, func(changes []mrs.Change) {
+			timestamp := time.Now().Format("15:04:05")
+			for _, change := range changes {
+				fmt.Printf("[%s] %s\n", timestamp, change)
+			}
+
+			if notifications != nil {
+				if err := notifications.announce(changeEvents(changes, session.listFlags.Resolved.Me)); err != nil {
+					log.Printf("Failed to announce merge requests: %v", err)
+				}
+			}
+		}); err != nil {
+			log.Printf("Failed to watch merge requests: %v", err)
+			return
+		}
+
+		fmt.Println("\nmacglab: stopped watching.")
+	},
+}
+
+// snapshotQueue runs list's query and captures the result, including pipeline statuses.
+func snapshotQueue(session *listSession) (mrs.Snapshot, error) {
+	allMrs, err := session.fetchMergeRequests()
+	if err != nil {
+		return mrs.Snapshot{}, err
+	}
+
+	details, err := mrs.FetchDetails(session.source, allMrs, session.listFlags.Resolved.Concurrency)
+	if err != nil {
+		return mrs.Snapshot{}, err
+	}
+
+	return mrs.NewSnapshot(allMrs, details), nil
+}
+
+// watch snapshots the queue every interval and calls onChange with what changed, until ctx is done.
+// A failed check is logged and retried on the next interval rather than ending the watch.
+func watch(ctx context.Context, session *listSession, interval time.Duration, onChange func(changes []mrs.Change)) error {
+	previous, err := snapshotQueue(session)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("macglab: watching %d MRs every %s. Press Ctrl+C to stop.\n", previous.Len(), interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		next, err := snapshotQueue(session)
+		if err != nil {
+			log.Printf("Failed to check merge requests, will retry in %s: %v", interval, err)
+			continue
+		}
+
+		// Don't report a check that finished after we were interrupted.
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		if changes := mrs.DiffSnapshots(previous, next); len(changes) > 0 {
+			onChange(changes)
+		}
+		previous = next
+	}
+}
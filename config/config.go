@@ -5,43 +5,84 @@ import (
 	"io"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/mjburtenshaw/macglab/cache"
 	"github.com/mjburtenshaw/macglab/files"
 	"github.com/mjburtenshaw/macglab/utils"
-	"gopkg.in/yaml.v2"
 )
 
 type Config struct {
-	AccessToken string              `yaml:"access_token"`
-	GroupId     string              `yaml:"group_id"`
-	Me          int                 `yaml:"me"`
-	Projects    map[string][]string `yaml:"projects"`
-	Usernames   []string            `yaml:"usernames"`
+	AccessToken        string              `yaml:"access_token"`
+	AccessTokenCommand string              `yaml:"access_token_command"`
+	BaseUrl            string              `yaml:"base_url"`
+	CacheTTL           string              `yaml:"cache_ttl"`
+	CaFile             string              `yaml:"ca_file"`
+	Concurrency        int                 `yaml:"concurrency"`
+	CurrentProfile     string              `yaml:"current_profile"`
+	GroupId            string              `yaml:"group_id"`
+	InsecureSkipVerify bool                `yaml:"insecure_skip_verify"`
+	MaxPages           int                 `yaml:"max_pages"`
+	Me                 string              `yaml:"me"`
+	Notifiers          []NotifierConfig    `yaml:"notifiers"`
+	PerPage            int                 `yaml:"per_page"`
+	Profiles           map[string]Config   `yaml:"profiles"`
+	Projects           map[string][]string `yaml:"projects"`
+	Templates          map[string]string   `yaml:"templates"`
+	Usernames          []string            `yaml:"usernames"`
+
+	// Profile is the name of the profile the config was read for. See ReadProfile.
+	Profile string `yaml:"-"`
 }
 
-type TrueUpKit struct {
-	ShouldAsk bool
-	Question string
-	ConfigAttr string
-	NextValue string
+// NotifierConfig configures somewhere to announce merge requests.
+type NotifierConfig struct {
+	// Name identifies the notifier. Each notifier announces an MR once.
+	Name string `yaml:"name"`
+	// Type is command, slack or webhook.
+	Type string `yaml:"type"`
+	// Events are the kinds of event to announce. Defaults depend on the type.
+	Events []string `yaml:"events"`
+	// Url is where webhooks are posted.
+	Url string `yaml:"url"`
+	// Headers are added to webhook requests, e.g. for authorization.
+	Headers map[string]string `yaml:"headers"`
+	// Command is the executable a command notifier runs. Defaults to notify-send on Linux.
+	Command string `yaml:"command"`
+	// Args are templates for the command's arguments.
+	Args []string `yaml:"args"`
+	// Stdin sends the event to the command as JSON on stdin.
+	Stdin bool `yaml:"stdin"`
+	// Template renders the message. See README for the defaults.
+	Template string `yaml:"template"`
 }
 
-func Read(configUrl string) (*Config, error) {
-	if err := files.CheckFileExists(configUrl); err != nil {
-		return nil, fmt.Errorf("couldn't find %s.\n\nPlease run `macglab init`.\n\n%w", configUrl, err)
+// GetCacheTTL parses cache_ttl, defaulting to cache.DefaultTTL when it isn't set.
+func (config *Config) GetCacheTTL() (time.Duration, error) {
+	if config.CacheTTL == "" {
+		return cache.DefaultTTL, nil
 	}
 
-	configFile, err := os.ReadFile(configUrl)
+	ttl, err := time.ParseDuration(config.CacheTTL)
 	if err != nil {
-		return nil, fmt.Errorf("couldn't read %s: %w", configUrl, err)
+		return 0, fmt.Errorf("couldn't parse cache_ttl %q. Use a duration like 5m or 1h: %w", config.CacheTTL, err)
 	}
 
-	var config *Config
-	if err = yaml.Unmarshal(configFile, &config); err != nil {
-		return nil, fmt.Errorf("couldn't unmarshal %s.\n\nPlease check for syntax errors in %s.\n\n: %w", configUrl, configUrl, err)
-	}
+	return ttl, nil
+}
+
+type TrueUpKit struct {
+	ShouldAsk bool
+	Question string
+	ConfigAttr string
+	NextValue string
+	// Store saves NextValue somewhere other than the config, e.g. a keyring for secrets. Optional.
+	Store func(value string) error
+}
 
-	return config, nil
+// Read reads the config for the current profile. See ReadProfile.
+func Read(configUrl string) (*Config, error) {
+	return ReadProfile(configUrl, "")
 }
 
 func Create(sampleConfigUrl string, configUrl string) (err error) {
@@ -72,43 +113,9 @@ func Create(sampleConfigUrl string, configUrl string) (err error) {
 	return nil
 }
 
-func Update(configUrl string, key string, NextValue string) (err error) {
-	configFile, err := os.OpenFile(configUrl, os.O_RDWR, 0)
-	if err != nil {
-		return fmt.Errorf("couldn't open config file: %w", err)
-	}
-	defer func() {
-		if cerr := configFile.Close(); cerr != nil && err == nil {
-			err = fmt.Errorf("couldn't close config file: %s", cerr)
-		}
-	}()
-
-	data, err := io.ReadAll(configFile)
-	if err != nil {
-		return fmt.Errorf("couldn't read config file: %w", err)
-	}
-
-	var content map[interface{}]interface{}
-	if err = yaml.Unmarshal(data, &content); err != nil {
-		return fmt.Errorf("couldn't unmarshal config file: %w", err)
-	}
-
-	if _, ok := content[key]; ok {
-		content[key] = NextValue
-	} else {
-		return fmt.Errorf("invalid key: %s", key)
-	}
-
-	output, err := yaml.Marshal(content)
-	if err != nil {
-		return fmt.Errorf("couldn't marshal config file: %w", err)
-	}
-
-	if err = os.WriteFile(configUrl, output, 0644); err != nil {
-		return fmt.Errorf("couldn't update config file: %w", err)
-	}
-
-	return nil
+// Update sets a key in the config at configUrl. See Set.
+func Update(configUrl string, key string, NextValue string) error {
+	return Set(configUrl, key, NextValue)
 }
 
 func TrueUp(trueUpKits []TrueUpKit) {
@@ -116,6 +123,12 @@ func TrueUp(trueUpKits []TrueUpKit) {
 		if trueUpKit.ShouldAsk {
 			response := utils.AskBinaryQuestion(trueUpKit.Question)
 			if strings.HasPrefix(strings.ToLower(response), "y") {
+				if trueUpKit.Store != nil {
+					if err := trueUpKit.Store(trueUpKit.NextValue); err != nil {
+						fmt.Printf("macglab: couldn't save %s: %v\n", trueUpKit.ConfigAttr, err)
+					}
+					continue
+				}
 				Update(files.MacglabConfigUrl, trueUpKit.ConfigAttr, trueUpKit.NextValue)
 			}
 		}
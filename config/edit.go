@@ -0,0 +1,429 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Parse strictly unmarshals and checks a config, so typos and values of the wrong type are errors.
+func Parse(data []byte) (*Config, error) {
+	var config Config
+	if err := yaml.UnmarshalStrict(data, &config); err != nil {
+		return nil, err
+	}
+
+	if err := config.check(); err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+// Validate reads and parses the config at configUrl.
+func Validate(configUrl string) (*Config, error) {
+	data, err := os.ReadFile(configUrl)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read %s: %w", configUrl, err)
+	}
+
+	config, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", configUrl, err)
+	}
+
+	return config, nil
+}
+
+// check reports values that unmarshal but can't be used.
+func (config *Config) check() error {
+	var errs []error
+
+	if config.BaseUrl != "" {
+		if baseUrl, err := url.Parse(config.BaseUrl); err != nil || baseUrl.Scheme == "" || baseUrl.Host == "" {
+			errs = append(errs, fmt.Errorf("base_url %q isn't a URL like https://gitlab.com", config.BaseUrl))
+		}
+	}
+	if _, err := config.GetCacheTTL(); err != nil {
+		errs = append(errs, err)
+	}
+	if config.Concurrency < 0 {
+		errs = append(errs, fmt.Errorf("concurrency can't be negative, got %d", config.Concurrency))
+	}
+	if config.MaxPages < 0 {
+		errs = append(errs, fmt.Errorf("max_pages can't be negative, got %d", config.MaxPages))
+	}
+	if id, err := strconv.Atoi(config.Me); err == nil && id < 0 {
+		errs = append(errs, fmt.Errorf("me can't be negative, got %d", id))
+	}
+	if config.PerPage < 0 || config.PerPage > 100 {
+		errs = append(errs, fmt.Errorf("per_page must be between 1 and 100, got %d", config.PerPage))
+	}
+	errs = append(errs, config.checkProjects()...)
+	errs = append(errs, config.checkProfiles()...)
+
+	return errors.Join(errs...)
+}
+
+// errInvalidChange is returned when an edit would leave the config invalid. The config isn't written.
+var errInvalidChange = errors.New("the change would make your config invalid")
+
+// BackupSuffix is appended to the config's path to name the backup Write keeps of the previous version.
+const BackupSuffix = ".bak"
+
+// Write replaces the config at configUrl with data. The previous version is kept at configUrl + BackupSuffix,
+// and both are written to a temporary file first, then renamed, so a crash never leaves a config half written.
+func Write(configUrl string, data []byte) error {
+	perm := os.FileMode(0644)
+
+	previous, err := os.ReadFile(configUrl)
+	switch {
+	case err == nil:
+		if info, err := os.Stat(configUrl); err == nil {
+			perm = info.Mode().Perm()
+		}
+		if err := writeAtomically(configUrl+BackupSuffix, previous, perm); err != nil {
+			return fmt.Errorf("couldn't back up %s: %w", configUrl, err)
+		}
+	case !os.IsNotExist(err):
+		return fmt.Errorf("couldn't read %s: %w", configUrl, err)
+	}
+
+	if err := writeAtomically(configUrl, data, perm); err != nil {
+		return fmt.Errorf("couldn't write %s: %w", configUrl, err)
+	}
+
+	return nil
+}
+
+func writeAtomically(fileUrl string, data []byte, perm os.FileMode) error {
+	tempFile, err := os.CreateTemp(filepath.Dir(fileUrl), filepath.Base(fileUrl)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile.Name())
+
+	if _, err := tempFile.Write(data); err != nil {
+		tempFile.Close()
+		return err
+	}
+	if err := tempFile.Sync(); err != nil {
+		tempFile.Close()
+		return err
+	}
+	if err := tempFile.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tempFile.Name(), perm); err != nil {
+		return err
+	}
+
+	return os.Rename(tempFile.Name(), fileUrl)
+}
+
+// Get returns the value at key, a dotted path like `usernames`, `projects.123` or `notifiers.0.url`,
+// as a YAML node so it can be printed as written, comments and all.
+func Get(configUrl string, key string) (*yamlv3.Node, error) {
+	segments, err := splitKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	document, err := readDocument(configUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	node, ok := lookup(document.Content[0], segments)
+	if !ok {
+		return nil, fmt.Errorf("%s isn't set", key)
+	}
+
+	return node, nil
+}
+
+// Set sets key to value, creating any maps on the way. value is parsed as YAML, so `5` is a
+// number and `[alice, bob]` is a list, unless that makes the config invalid, e.g. a template
+// like `{{.Title}}`, in which case it's set as a string.
+func Set(configUrl string, key string, value string) error {
+	segments, err := splitKey(key)
+	if err != nil {
+		return err
+	}
+
+	parsedValue := parseValue(value)
+	err = set(configUrl, segments, parsedValue)
+	if errors.Is(err, errInvalidChange) && parsedValue.ShortTag() != "!!str" {
+		if stringErr := set(configUrl, segments, stringNode(value)); stringErr == nil {
+			return nil
+		}
+	}
+
+	return err
+}
+
+func set(configUrl string, segments []string, value *yamlv3.Node) error {
+	return edit(configUrl, func(root *yamlv3.Node) error {
+		parent, err := demandParent(root, segments)
+		if err != nil {
+			return err
+		}
+
+		last := segments[len(segments)-1]
+		if existing, ok := lookup(parent, []string{last}); ok {
+			replaceValue(existing, value)
+			return nil
+		}
+		if parent.Kind != yamlv3.MappingNode {
+			return fmt.Errorf("%s has no item %s; use add to append to it", strings.Join(segments[:len(segments)-1], "."), last)
+		}
+		parent.Content = append(parent.Content, newKey(last), value)
+		return nil
+	})
+}
+
+// Add appends values to the list at key, creating it if it isn't set.
+func Add(configUrl string, key string, values []string) error {
+	segments, err := splitKey(key)
+	if err != nil {
+		return err
+	}
+
+	return edit(configUrl, func(root *yamlv3.Node) error {
+		parent, err := demandParent(root, segments)
+		if err != nil {
+			return err
+		}
+
+		list, ok := lookup(parent, segments[len(segments)-1:])
+		switch {
+		case !ok && parent.Kind == yamlv3.MappingNode:
+			list = &yamlv3.Node{Kind: yamlv3.SequenceNode, Tag: "!!seq"}
+			parent.Content = append(parent.Content, newKey(segments[len(segments)-1]), list)
+		case !ok:
+			return fmt.Errorf("%s isn't set", key)
+		case isNull(list):
+			list.Kind, list.Tag, list.Value = yamlv3.SequenceNode, "!!seq", ""
+		case list.Kind != yamlv3.SequenceNode:
+			return fmt.Errorf("%s isn't a list", key)
+		}
+
+		for _, value := range values {
+			list.Content = append(list.Content, parseValue(value))
+		}
+		return nil
+	})
+}
+
+// Unset removes key, or an item from a list if the last part of key is an index.
+func Unset(configUrl string, key string) error {
+	segments, err := splitKey(key)
+	if err != nil {
+		return err
+	}
+
+	return edit(configUrl, func(root *yamlv3.Node) error {
+		parent, ok := lookup(root, segments[:len(segments)-1])
+		if !ok {
+			return fmt.Errorf("%s isn't set", key)
+		}
+
+		last := segments[len(segments)-1]
+		switch parent.Kind {
+		case yamlv3.MappingNode:
+			if removeKey(parent, last) {
+				return nil
+			}
+		case yamlv3.SequenceNode:
+			index, err := strconv.Atoi(last)
+			if err == nil && index >= 0 && index < len(parent.Content) {
+				parent.Content = append(parent.Content[:index:index], parent.Content[index+1:]...)
+				return nil
+			}
+		}
+
+		return fmt.Errorf("%s isn't set", key)
+	})
+}
+
+// edit changes the config at configUrl as a YAML node tree, so comments, key order and the
+// types of untouched values survive. The config is only written if the result is still valid.
+func edit(configUrl string, change func(root *yamlv3.Node) error) error {
+	document, err := readDocument(configUrl)
+	if err != nil {
+		return err
+	}
+
+	if err := change(document.Content[0]); err != nil {
+		return err
+	}
+
+	var buffer bytes.Buffer
+	encoder := yamlv3.NewEncoder(&buffer)
+	encoder.SetIndent(4)
+	if err := encoder.Encode(document); err != nil {
+		return fmt.Errorf("couldn't marshal config: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return fmt.Errorf("couldn't marshal config: %w", err)
+	}
+
+	if _, err := Parse(buffer.Bytes()); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidChange, err)
+	}
+
+	return Write(configUrl, buffer.Bytes())
+}
+
+// readDocument reads the config as a YAML document whose only content is the top-level map.
+func readDocument(configUrl string) (*yamlv3.Node, error) {
+	data, err := os.ReadFile(configUrl)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read %s: %w", configUrl, err)
+	}
+
+	var document yamlv3.Node
+	if err := yamlv3.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("couldn't unmarshal %s: %w", configUrl, err)
+	}
+
+	if len(document.Content) == 0 {
+		document = yamlv3.Node{Kind: yamlv3.DocumentNode, HeadComment: document.HeadComment, Content: []*yamlv3.Node{{Kind: yamlv3.MappingNode, Tag: "!!map"}}}
+	}
+	if document.Content[0].Kind != yamlv3.MappingNode {
+		return nil, fmt.Errorf("couldn't read %s: it isn't a map of keys to values", configUrl)
+	}
+
+	return &document, nil
+}
+
+func splitKey(key string) ([]string, error) {
+	segments := strings.Split(key, ".")
+	for _, segment := range segments {
+		if segment == "" {
+			return nil, fmt.Errorf("invalid key %q; use a dotted path like projects.123", key)
+		}
+	}
+	return segments, nil
+}
+
+// parseValue parses a value typed on the command line as YAML. Values that aren't YAML are strings.
+func parseValue(value string) *yamlv3.Node {
+	var document yamlv3.Node
+	if err := yamlv3.Unmarshal([]byte(value), &document); err != nil || len(document.Content) == 0 {
+		return stringNode(value)
+	}
+	return document.Content[0]
+}
+
+func stringNode(value string) *yamlv3.Node {
+	return &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: value}
+}
+
+// lookup follows segments through maps and lists.
+func lookup(node *yamlv3.Node, segments []string) (*yamlv3.Node, bool) {
+	for _, segment := range segments {
+		if node.Kind == yamlv3.AliasNode {
+			node = node.Alias
+		}
+
+		switch node.Kind {
+		case yamlv3.MappingNode:
+			var child *yamlv3.Node
+			for i := 0; i < len(node.Content); i += 2 {
+				if node.Content[i].Value == segment {
+					child = node.Content[i+1]
+					break
+				}
+			}
+			if child == nil {
+				return nil, false
+			}
+			node = child
+		case yamlv3.SequenceNode:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(node.Content) {
+				return nil, false
+			}
+			node = node.Content[index]
+		default:
+			return nil, false
+		}
+	}
+	return node, true
+}
+
+// demandParent returns the map or list holding the last segment, creating maps for missing or
+// empty keys on the way.
+func demandParent(root *yamlv3.Node, segments []string) (*yamlv3.Node, error) {
+	return demand(root, segments[:len(segments)-1])
+}
+
+// demand returns the map or list at segments, creating maps for missing or empty keys.
+func demand(root *yamlv3.Node, segments []string) (*yamlv3.Node, error) {
+	node := root
+	for depth, segment := range segments {
+		child, ok := lookup(node, []string{segment})
+		switch {
+		case !ok && node.Kind == yamlv3.MappingNode:
+			child = &yamlv3.Node{Kind: yamlv3.MappingNode, Tag: "!!map"}
+			node.Content = append(node.Content, newKey(segment), child)
+		case !ok:
+			return nil, fmt.Errorf("%s has no item %s; use add to append to it", strings.Join(segments[:depth], "."), segment)
+		case isNull(child):
+			// Keep comments on empty keys, like `789: # projectC`.
+			child.Kind, child.Tag, child.Value = yamlv3.MappingNode, "!!map", ""
+		}
+
+		if child.Kind != yamlv3.MappingNode && child.Kind != yamlv3.SequenceNode {
+			return nil, fmt.Errorf("%s isn't a map or a list", strings.Join(segments[:depth+1], "."))
+		}
+		node = child
+	}
+	return node, nil
+}
+
+// removeKey removes a key and its value from a map. It reports whether the key was there.
+func removeKey(node *yamlv3.Node, key string) bool {
+	if node.Kind != yamlv3.MappingNode {
+		return false
+	}
+	for i := 0; i < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content = append(node.Content[:i:i], node.Content[i+2:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// replaceValue overwrites old with value in place, keeping old's comments. A string replacing a
+// string keeps its quotes.
+func replaceValue(old *yamlv3.Node, value *yamlv3.Node) {
+	if old.Kind == yamlv3.ScalarNode && value.Kind == yamlv3.ScalarNode && old.ShortTag() == "!!str" && value.ShortTag() == "!!str" {
+		value.Style = old.Style
+	}
+	value.HeadComment, value.LineComment, value.FootComment = old.HeadComment, old.LineComment, old.FootComment
+	*old = *value
+}
+
+func isNull(node *yamlv3.Node) bool {
+	return node.Kind == yamlv3.ScalarNode && node.ShortTag() == "!!null"
+}
+
+// newKey writes numeric keys, like project IDs, as numbers, the same as the sample config.
+func newKey(segment string) *yamlv3.Node {
+	tag := "!!str"
+	if _, err := strconv.Atoi(segment); err == nil {
+		tag = "!!int"
+	}
+	return &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: tag, Value: segment}
+}
@@ -0,0 +1,255 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testConfig = `access_token: secret
+group_id: "42"
+me: 7
+projects:
+    all:
+        - erin
+    123:
+        - alice
+usernames:
+    - alice
+    - bob
+`
+
+func writeTestConfig(t *testing.T) string {
+	t.Helper()
+	configUrl := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(configUrl, []byte(testConfig), 0644); err != nil {
+		t.Fatalf("couldn't write config: %v", err)
+	}
+	return configUrl
+}
+
+func readTestConfig(t *testing.T, configUrl string) *Config {
+	t.Helper()
+	config, err := Validate(configUrl)
+	if err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	return config
+}
+
+func TestGet(t *testing.T) {
+	configUrl := writeTestConfig(t)
+
+	tests := []struct {
+		key  string
+		want interface{}
+	}{
+		{key: "me", want: 7},
+		{key: "projects.123", want: []interface{}{"alice"}},
+		{key: "usernames.1", want: "bob"},
+	}
+	for _, tt := range tests {
+		node, err := Get(configUrl, tt.key)
+		if err != nil {
+			t.Fatalf("Get(%q) error = %v", tt.key, err)
+		}
+		var got interface{}
+		if err := node.Decode(&got); err != nil {
+			t.Fatalf("couldn't decode %s: %v", tt.key, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Get(%q) = %#v, want %#v", tt.key, got, tt.want)
+		}
+	}
+
+	for _, key := range []string{"projects.456", "usernames.2", "me.id", "projects..all"} {
+		if _, err := Get(configUrl, key); err == nil {
+			t.Errorf("Get(%q) error = nil, want an error", key)
+		}
+	}
+}
+
+func TestSetAddAndUnset(t *testing.T) {
+	configUrl := writeTestConfig(t)
+
+	if err := Set(configUrl, "me", "12"); err != nil {
+		t.Fatalf("Set(me) error = %v", err)
+	}
+	if err := Set(configUrl, "projects.456", "[dave]"); err != nil {
+		t.Fatalf("Set(projects.456) error = %v", err)
+	}
+	if err := Set(configUrl, "templates.short", "{{.Title}}"); err != nil {
+		t.Fatalf("Set(templates.short) error = %v", err)
+	}
+	if err := Add(configUrl, "usernames", []string{"carol", "dave"}); err != nil {
+		t.Fatalf("Add(usernames) error = %v", err)
+	}
+	if err := Add(configUrl, "projects.123", []string{"mia"}); err != nil {
+		t.Fatalf("Add(projects.123) error = %v", err)
+	}
+	if err := Unset(configUrl, "usernames.0"); err != nil {
+		t.Fatalf("Unset(usernames.0) error = %v", err)
+	}
+	if err := Unset(configUrl, "projects.all"); err != nil {
+		t.Fatalf("Unset(projects.all) error = %v", err)
+	}
+
+	config := readTestConfig(t, configUrl)
+	if config.Me != "12" {
+		t.Errorf("me = %s, want 12", config.Me)
+	}
+	wantProjects := map[string][]string{"123": {"alice", "mia"}, "456": {"dave"}}
+	if !reflect.DeepEqual(config.Projects, wantProjects) {
+		t.Errorf("projects = %v, want %v", config.Projects, wantProjects)
+	}
+	if want := []string{"bob", "carol", "dave"}; !reflect.DeepEqual(config.Usernames, want) {
+		t.Errorf("usernames = %v, want %v", config.Usernames, want)
+	}
+	if config.Templates["short"] != "{{.Title}}" {
+		t.Errorf("templates = %v, want short", config.Templates)
+	}
+	if config.AccessToken != "secret" {
+		t.Errorf("access_token = %q, want it untouched", config.AccessToken)
+	}
+}
+
+func TestEditsThatBreakTheConfigAreRejected(t *testing.T) {
+	configUrl := writeTestConfig(t)
+
+	tests := []struct {
+		name string
+		edit func() error
+		want string
+	}{
+		{name: "wrong type", edit: func() error { return Set(configUrl, "concurrency", "lots") }, want: "cannot unmarshal"},
+		{name: "unknown key", edit: func() error { return Set(configUrl, "usrenames", "[alice]") }, want: "not found"},
+		{name: "bad value", edit: func() error { return Set(configUrl, "per_page", "500") }, want: "per_page"},
+		{name: "not a list", edit: func() error { return Add(configUrl, "me", []string{"8"}) }, want: "isn't a list"},
+		{name: "missing key", edit: func() error { return Unset(configUrl, "ca_file") }, want: "isn't set"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.edit()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("error = %v, want it to mention %q", err, tt.want)
+			}
+
+			data, err := os.ReadFile(configUrl)
+			if err != nil {
+				t.Fatalf("couldn't read config: %v", err)
+			}
+			if string(data) != testConfig {
+				t.Errorf("config changed despite the error:\n%s", data)
+			}
+		})
+	}
+}
+
+func TestEditsKeepCommentsOrderAndTypes(t *testing.T) {
+	sample, err := os.ReadFile("../config.sample.yml")
+	if err != nil {
+		t.Fatalf("couldn't read the sample config: %v", err)
+	}
+	configUrl := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(configUrl, sample, 0600); err != nil {
+		t.Fatalf("couldn't write config: %v", err)
+	}
+
+	if err := Update(configUrl, "me", "12345"); err != nil {
+		t.Fatalf("Update(me) error = %v", err)
+	}
+	if err := Set(configUrl, "group_id", "678"); err != nil {
+		t.Fatalf("Set(group_id) error = %v", err)
+	}
+	if err := Set(configUrl, "ca_file", "/etc/ssl/gitlab.pem"); err != nil {
+		t.Fatalf("Set(ca_file) error = %v", err)
+	}
+	if err := Add(configUrl, "projects.789", []string{"username5"}); err != nil {
+		t.Fatalf("Add(projects.789) error = %v", err)
+	}
+
+	data, err := os.ReadFile(configUrl)
+	if err != nil {
+		t.Fatalf("couldn't read config: %v", err)
+	}
+	edited := string(data)
+
+	for _, want := range []string{
+		"# See [macglab > Configuration]",
+		"me: 12345 # optional. your gitlab username or user ID.",
+		"group_id: 678\n",
+		"ca_file: /etc/ssl/gitlab.pem # optional. a PEM file of extra certificate authorities to trust.\n",
+		"789: # projectC",
+		"- username5",
+		"# usernames listed under the \"all\" entry will apply to every project.",
+	} {
+		if !strings.Contains(edited, want) {
+			t.Errorf("edited config doesn't contain %q:\n%s", want, edited)
+		}
+	}
+
+	var keys []string
+	for _, line := range strings.Split(edited, "\n") {
+		if key, _, ok := strings.Cut(line, ":"); ok && key != "" && !strings.HasPrefix(key, " ") && !strings.HasPrefix(key, "#") {
+			keys = append(keys, key)
+		}
+	}
+	wantKeys := []string{"access_token", "access_token_command", "base_url", "ca_file", "cache_ttl", "concurrency", "current_profile", "group_id", "insecure_skip_verify", "max_pages", "me", "notifiers", "per_page", "profiles", "projects", "templates", "usernames"}
+	if !reflect.DeepEqual(keys, wantKeys) {
+		t.Errorf("keys = %v, want %v", keys, wantKeys)
+	}
+
+	config, err := Read(configUrl)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if config.Me != "12345" || config.GroupId != "678" {
+		t.Errorf("me = %q, group_id = %q, want 12345 and 678", config.Me, config.GroupId)
+	}
+
+	info, err := os.Stat(configUrl)
+	if err != nil {
+		t.Fatalf("couldn't stat config: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("config mode = %v, want 0600", info.Mode().Perm())
+	}
+}
+
+func TestWriteKeepsABackup(t *testing.T) {
+	configUrl := writeTestConfig(t)
+
+	if err := Set(configUrl, "me", "8"); err != nil {
+		t.Fatalf("Set(me) error = %v", err)
+	}
+	if err := Set(configUrl, "me", "9"); err != nil {
+		t.Fatalf("Set(me) error = %v", err)
+	}
+
+	backup, err := Parse(mustReadFile(t, configUrl+BackupSuffix))
+	if err != nil {
+		t.Fatalf("couldn't parse the backup: %v", err)
+	}
+	if backup.Me != "8" {
+		t.Errorf("backup me = %s, want the previous version's 8", backup.Me)
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(configUrl))
+	if err != nil {
+		t.Fatalf("couldn't read dir: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("found %d files, want only the config and its backup", len(entries))
+	}
+}
+
+func mustReadFile(t *testing.T, fileUrl string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(fileUrl)
+	if err != nil {
+		t.Fatalf("couldn't read %s: %v", fileUrl, err)
+	}
+	return data
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// EnvPrefix starts the name of each environment variable that overrides a config key, e.g. MACGLAB_ACCESS_TOKEN.
+const EnvPrefix = "MACGLAB_"
+
+// EnvName returns the environment variable that overrides a top-level config key, e.g. MACGLAB_GROUP_ID for group_id.
+func EnvName(key string) string {
+	return EnvPrefix + strings.ToUpper(key)
+}
+
+// EnvOverrides returns the MACGLAB_* environment variables that override config keys, in the order Config declares them.
+func EnvOverrides() []string {
+	var names []string
+	configType := reflect.TypeOf(Config{})
+	for i := 0; i < configType.NumField(); i++ {
+		key := yamlKey(configType.Field(i))
+		if key != "" && strings.TrimSpace(os.Getenv(EnvName(key))) != "" {
+			names = append(names, EnvName(key))
+		}
+	}
+	return names
+}
+
+// envContent reads the MACGLAB_* environment variables that are set and not empty into top-level config keys.
+// Strings, numbers and booleans are taken as they are, lists of strings like MACGLAB_USERNAMES are CSVs, and
+// everything else, like MACGLAB_NOTIFIERS or MACGLAB_PROJECTS, is YAML or JSON.
+func envContent() (map[string]interface{}, error) {
+	content := map[string]interface{}{}
+	var errs []error
+
+	configType := reflect.TypeOf(Config{})
+	for i := 0; i < configType.NumField(); i++ {
+		field := configType.Field(i)
+		key := yamlKey(field)
+		if key == "" {
+			continue
+		}
+
+		value := strings.TrimSpace(os.Getenv(EnvName(key)))
+		if value == "" {
+			continue
+		}
+
+		parsed, err := parseEnvValue(field.Type, value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("couldn't parse %s: %w", EnvName(key), err))
+			continue
+		}
+		content[key] = parsed
+	}
+
+	return content, errors.Join(errs...)
+}
+
+// parseEnvValue parses an environment variable into the value YAML would hold for a field of the given type.
+func parseEnvValue(fieldType reflect.Type, value string) (interface{}, error) {
+	switch fieldType.Kind() {
+	case reflect.String:
+		return value, nil
+	case reflect.Int:
+		return strconv.Atoi(value)
+	case reflect.Bool:
+		return strconv.ParseBool(value)
+	case reflect.Slice:
+		if fieldType.Elem().Kind() == reflect.String {
+			items := []interface{}{}
+			for _, item := range strings.Split(value, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					items = append(items, item)
+				}
+			}
+			return items, nil
+		}
+	}
+
+	var parsed interface{}
+	if err := yaml.Unmarshal([]byte(value), &parsed); err != nil {
+		return nil, err
+	}
+	return parsed, nil
+}
+
+// yamlKey returns the config key a field is read from, or an empty string if it isn't read from the config.
+func yamlKey(field reflect.StructField) string {
+	key := strings.Split(field.Tag.Get("yaml"), ",")[0]
+	if key == "-" {
+		return ""
+	}
+	return key
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadProfileEnv(t *testing.T) {
+	t.Run("environment overrides the file and the profile", func(t *testing.T) {
+		configUrl := writeProfilesConfig(t)
+		t.Setenv("MACGLAB_ACCESS_TOKEN", "ci-token")
+		t.Setenv("MACGLAB_ME", "11")
+		t.Setenv("MACGLAB_USERNAMES", "carol, dave,")
+		t.Setenv("MACGLAB_INSECURE_SKIP_VERIFY", "true")
+
+		config, err := ReadProfile(configUrl, "")
+		if err != nil {
+			t.Fatalf("ReadProfile() error = %v", err)
+		}
+		config.CurrentProfile, config.Profiles, config.Profile = "", nil, ""
+
+		want := Config{AccessToken: "ci-token", GroupId: "99", InsecureSkipVerify: true, Me: "11", Usernames: []string{"carol", "dave"}}
+		if !reflect.DeepEqual(*config, want) {
+			t.Errorf("ReadProfile() = %+v, want %+v", *config, want)
+		}
+	})
+
+	t.Run("environment chooses the profile", func(t *testing.T) {
+		configUrl := writeProfilesConfig(t)
+		t.Setenv("MACGLAB_CURRENT_PROFILE", "staging")
+
+		config, err := ReadProfile(configUrl, "")
+		if err != nil {
+			t.Fatalf("ReadProfile() error = %v", err)
+		}
+		if config.Profile != "staging" || config.BaseUrl != "https://gitlab.staging.example.com" {
+			t.Errorf("ReadProfile() = profile %q with base_url %q, want the staging profile", config.Profile, config.BaseUrl)
+		}
+	})
+
+	t.Run("environment is enough without a file", func(t *testing.T) {
+		configUrl := filepath.Join(t.TempDir(), "config.yml")
+		t.Setenv("MACGLAB_ACCESS_TOKEN", "ci-token")
+		t.Setenv("MACGLAB_GROUP_ID", "123")
+		t.Setenv("MACGLAB_PROJECTS", `{"456": [alice]}`)
+		t.Setenv("MACGLAB_NOTIFIERS", `[{name: ci, type: webhook, url: "https://example.com/hook"}]`)
+
+		config, err := ReadProfile(configUrl, "")
+		if err != nil {
+			t.Fatalf("ReadProfile() error = %v", err)
+		}
+
+		want := Config{
+			AccessToken: "ci-token",
+			GroupId:     "123",
+			Notifiers:   []NotifierConfig{{Name: "ci", Type: "webhook", Url: "https://example.com/hook"}},
+			Projects:    map[string][]string{"456": {"alice"}},
+			Profile:     DefaultProfile,
+		}
+		if !reflect.DeepEqual(*config, want) {
+			t.Errorf("ReadProfile() = %+v, want %+v", *config, want)
+		}
+	})
+
+	t.Run("no file and no environment", func(t *testing.T) {
+		configUrl := filepath.Join(t.TempDir(), "config.yml")
+		if _, err := ReadProfile(configUrl, ""); err == nil || !strings.Contains(err.Error(), "macglab init") {
+			t.Errorf("ReadProfile() error = %v, want it to suggest macglab init", err)
+		}
+	})
+
+	t.Run("invalid value", func(t *testing.T) {
+		configUrl := writeProfilesConfig(t)
+		t.Setenv("MACGLAB_CONCURRENCY", "lots")
+		if _, err := ReadProfile(configUrl, ""); err == nil || !strings.Contains(err.Error(), "MACGLAB_CONCURRENCY") {
+			t.Errorf("ReadProfile() error = %v, want it to name MACGLAB_CONCURRENCY", err)
+		}
+	})
+}
+
+func TestEnvOverrides(t *testing.T) {
+	t.Setenv("MACGLAB_USERNAMES", "alice")
+	t.Setenv("MACGLAB_ACCESS_TOKEN", "token")
+	t.Setenv("MACGLAB_BASE_URL", " ")
+
+	want := []string{"MACGLAB_ACCESS_TOKEN", "MACGLAB_USERNAMES"}
+	if got := EnvOverrides(); !reflect.DeepEqual(got, want) {
+		t.Errorf("EnvOverrides() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,242 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/mjburtenshaw/macglab/files"
+	"gopkg.in/yaml.v2"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// DefaultProfile is the name of the profile made of the config's top-level keys.
+const DefaultProfile = "default"
+
+// profileKeys are top-level keys that choose and hold profiles, so they can't be set within one.
+var profileKeys = []string{"current_profile", "profiles"}
+
+// ReadProfile reads the config for the named profile, or the current profile if name is empty.
+// A profile only needs the keys that differ from the top-level ones, e.g. access_token, group_id
+// and me; every other key falls back to the top-level value. MACGLAB_* environment variables
+// override both, and are enough on their own when there's no config file, e.g. in CI.
+func ReadProfile(configUrl string, name string) (*Config, error) {
+	env, err := envContent()
+	if err != nil {
+		return nil, err
+	}
+
+	content := map[string]interface{}{}
+	if err := files.CheckFileExists(configUrl); err != nil {
+		if len(env) == 0 {
+			return nil, fmt.Errorf("couldn't find %s.\n\nPlease run `macglab init`, or set %s* environment variables.\n\n%w", configUrl, EnvPrefix, err)
+		}
+	} else {
+		configFile, err := os.ReadFile(configUrl)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't read %s: %w", configUrl, err)
+		}
+		if err = yaml.Unmarshal(configFile, &content); err != nil {
+			return nil, fmt.Errorf("couldn't unmarshal %s.\n\nPlease check for syntax errors in %s.\n\n: %w", configUrl, configUrl, err)
+		}
+	}
+
+	for key, value := range env {
+		content[key] = value
+	}
+
+	config, err := decode(content)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't unmarshal %s.\n\nPlease check for mistakes in %s and your %s* environment variables.\n\n: %w", configUrl, configUrl, EnvPrefix, err)
+	}
+
+	if name == "" {
+		name = config.CurrentProfile
+	}
+	if name == "" || name == DefaultProfile {
+		config.Profile = DefaultProfile
+		return config, nil
+	}
+
+	profileConfig, err := overlayProfile(content, env, name)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read profile %s from %s: %w", name, configUrl, err)
+	}
+	profileConfig.CurrentProfile = config.CurrentProfile
+	profileConfig.Profiles = config.Profiles
+	profileConfig.Profile = name
+
+	return profileConfig, nil
+}
+
+// overlayProfile replaces the top-level keys with the ones the named profile sets, then with the
+// environment's. It works on the raw YAML so a profile can override a value with its zero value,
+// e.g. insecure_skip_verify: false.
+func overlayProfile(content map[string]interface{}, env map[string]interface{}, name string) (*Config, error) {
+	profiles, _ := content["profiles"].(map[interface{}]interface{})
+	var profile interface{}
+	found := false
+	for profileName, value := range profiles {
+		if fmt.Sprint(profileName) == name {
+			profile, found = value, true
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("there's no profile named %q; run `macglab profile list` to see them", name)
+	}
+
+	merged := map[string]interface{}{}
+	for key, value := range content {
+		merged[key] = value
+	}
+
+	profileContent, ok := profile.(map[interface{}]interface{})
+	if !ok && profile != nil {
+		return nil, fmt.Errorf("profile %q isn't a map of keys to values", name)
+	}
+	for key, value := range profileContent {
+		merged[fmt.Sprint(key)] = value
+	}
+	for key, value := range env {
+		merged[key] = value
+	}
+	for _, key := range profileKeys {
+		delete(merged, key)
+	}
+
+	return decode(merged)
+}
+
+// decode turns raw YAML content into a Config.
+func decode(content map[string]interface{}) (*Config, error) {
+	data, err := yaml.Marshal(content)
+	if err != nil {
+		return nil, err
+	}
+
+	var config Config
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+// ProfileNames returns the default profile followed by the named profiles, sorted.
+func (config *Config) ProfileNames() []string {
+	names := []string{}
+	for name := range config.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return append([]string{DefaultProfile}, names...)
+}
+
+// KeyPath returns where key is set for the config's profile, e.g. `profiles.oss.me`, for Set and Update.
+func (config *Config) KeyPath(key string) string {
+	if config.Profile == "" || config.Profile == DefaultProfile {
+		return key
+	}
+	return fmt.Sprintf("profiles.%s.%s", config.Profile, key)
+}
+
+// checkProfiles reports profiles that can't be used.
+func (config *Config) checkProfiles() []error {
+	var errs []error
+
+	if config.CurrentProfile != "" && config.CurrentProfile != DefaultProfile {
+		if _, ok := config.Profiles[config.CurrentProfile]; !ok {
+			errs = append(errs, fmt.Errorf("current_profile %q isn't one of your profiles", config.CurrentProfile))
+		}
+	}
+
+	for _, name := range config.ProfileNames()[1:] {
+		profile := config.Profiles[name]
+		if name == DefaultProfile {
+			errs = append(errs, fmt.Errorf("profiles can't be named %s; the top-level keys are the %s profile", DefaultProfile, DefaultProfile))
+		}
+		if strings.Contains(name, ".") {
+			errs = append(errs, fmt.Errorf("profile %q can't contain a dot", name))
+		}
+		if profile.CurrentProfile != "" || len(profile.Profiles) > 0 {
+			errs = append(errs, fmt.Errorf("profile %s can't set %s", name, strings.Join(profileKeys, " or ")))
+		}
+		if err := profile.check(); err != nil {
+			errs = append(errs, fmt.Errorf("profile %s: %w", name, err))
+		}
+	}
+
+	return errs
+}
+
+// AddProfile adds a named profile with the given top-level keys, e.g. `group_id`, overridden.
+// Values are parsed the same way as Set's.
+func AddProfile(configUrl string, name string, values map[string]string) error {
+	if name == DefaultProfile {
+		return fmt.Errorf("the top-level keys are already the %s profile", DefaultProfile)
+	}
+
+	return edit(configUrl, func(root *yamlv3.Node) error {
+		if _, ok := lookup(root, []string{"profiles", name}); ok {
+			return fmt.Errorf("there's already a profile named %q", name)
+		}
+
+		profile, err := demand(root, []string{"profiles", name})
+		if err != nil {
+			return err
+		}
+
+		keys := make([]string, 0, len(values))
+		for key := range values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			profile.Content = append(profile.Content, newKey(key), parseValue(values[key]))
+		}
+
+		return nil
+	})
+}
+
+// RemoveProfile removes a named profile. If it was the current profile, the default profile becomes current.
+func RemoveProfile(configUrl string, name string) error {
+	if name == DefaultProfile {
+		return fmt.Errorf("the %s profile is the top-level keys; it can't be removed", DefaultProfile)
+	}
+
+	return edit(configUrl, func(root *yamlv3.Node) error {
+		profiles, ok := lookup(root, []string{"profiles"})
+		if !ok || !removeKey(profiles, name) {
+			return fmt.Errorf("there's no profile named %q", name)
+		}
+		if len(profiles.Content) == 0 {
+			removeKey(root, "profiles")
+		}
+
+		if current, ok := lookup(root, []string{"current_profile"}); ok && current.Value == name {
+			removeKey(root, "current_profile")
+		}
+
+		return nil
+	})
+}
+
+// UseProfile makes the named profile current.
+func UseProfile(configUrl string, name string) error {
+	return edit(configUrl, func(root *yamlv3.Node) error {
+		if name != DefaultProfile {
+			if _, ok := lookup(root, []string{"profiles", name}); !ok {
+				return fmt.Errorf("there's no profile named %q", name)
+			}
+		}
+
+		if current, ok := lookup(root, []string{"current_profile"}); ok {
+			replaceValue(current, stringNode(name))
+			return nil
+		}
+		root.Content = append([]*yamlv3.Node{newKey("current_profile"), stringNode(name)}, root.Content...)
+		return nil
+	})
+}
@@ -0,0 +1,169 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const profilesConfig = `access_token: work-token
+group_id: "42"
+insecure_skip_verify: true
+me: 7
+usernames:
+    - alice
+current_profile: oss
+profiles:
+    oss:
+        access_token: oss-token # my open-source token
+        group_id: "99"
+        insecure_skip_verify: false
+        usernames:
+            - octo
+    staging:
+        base_url: https://gitlab.staging.example.com
+`
+
+func writeProfilesConfig(t *testing.T) string {
+	t.Helper()
+	configUrl := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(configUrl, []byte(profilesConfig), 0644); err != nil {
+		t.Fatalf("couldn't write config: %v", err)
+	}
+	return configUrl
+}
+
+func TestReadProfile(t *testing.T) {
+	configUrl := writeProfilesConfig(t)
+
+	tests := []struct {
+		name      string
+		profile   string
+		want      Config
+		wantError string
+	}{
+		{
+			name:    "current profile overrides top-level keys, even with zero values",
+			profile: "",
+			want:    Config{AccessToken: "oss-token", GroupId: "99", Me: "7", Usernames: []string{"octo"}},
+		},
+		{
+			name:    "default profile is the top-level keys",
+			profile: DefaultProfile,
+			want:    Config{AccessToken: "work-token", GroupId: "42", InsecureSkipVerify: true, Me: "7", Usernames: []string{"alice"}},
+		},
+		{
+			name:    "profiles fall back to top-level keys they don't set",
+			profile: "staging",
+			want:    Config{AccessToken: "work-token", BaseUrl: "https://gitlab.staging.example.com", GroupId: "42", InsecureSkipVerify: true, Me: "7", Usernames: []string{"alice"}},
+		},
+		{
+			name:      "unknown profile",
+			profile:   "personal",
+			wantError: `there's no profile named "personal"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := ReadProfile(configUrl, tt.profile)
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Fatalf("ReadProfile() error = %v, want %q", err, tt.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadProfile() error = %v", err)
+			}
+
+			if config.CurrentProfile != "oss" || len(config.Profiles) != 2 {
+				t.Errorf("ReadProfile() lost the profiles: current %q, %d profiles", config.CurrentProfile, len(config.Profiles))
+			}
+
+			got := *config
+			got.CurrentProfile, got.Profiles, got.Profile = "", nil, ""
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadProfile() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProfileCommands(t *testing.T) {
+	configUrl := writeProfilesConfig(t)
+
+	if err := AddProfile(configUrl, "personal", map[string]string{"group_id": `"123"`, "me": "8"}); err != nil {
+		t.Fatalf("AddProfile() error = %v", err)
+	}
+	if err := AddProfile(configUrl, "personal", nil); err == nil {
+		t.Errorf("AddProfile() of an existing profile error = nil, want an error")
+	}
+	if err := AddProfile(configUrl, DefaultProfile, nil); err == nil {
+		t.Errorf("AddProfile(default) error = nil, want an error")
+	}
+
+	if err := UseProfile(configUrl, "personal"); err != nil {
+		t.Fatalf("UseProfile() error = %v", err)
+	}
+	if err := UseProfile(configUrl, "nope"); err == nil {
+		t.Errorf("UseProfile() of a missing profile error = nil, want an error")
+	}
+
+	config, err := Read(configUrl)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if config.Profile != "personal" || config.GroupId != "123" || config.Me != "8" || config.AccessToken != "work-token" {
+		t.Errorf("Read() = %+v, want the personal profile over the top-level keys", config)
+	}
+	if got := config.KeyPath("me"); got != "profiles.personal.me" {
+		t.Errorf("KeyPath(me) = %q, want profiles.personal.me", got)
+	}
+	if want := []string{DefaultProfile, "oss", "personal", "staging"}; !reflect.DeepEqual(config.ProfileNames(), want) {
+		t.Errorf("ProfileNames() = %v, want %v", config.ProfileNames(), want)
+	}
+
+	if err := RemoveProfile(configUrl, "personal"); err != nil {
+		t.Fatalf("RemoveProfile() error = %v", err)
+	}
+	config, err = Read(configUrl)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if config.Profile != DefaultProfile || config.KeyPath("me") != "me" {
+		t.Errorf("Read() after removing the current profile = %q, want the default profile", config.Profile)
+	}
+
+	data, err := os.ReadFile(configUrl)
+	if err != nil {
+		t.Fatalf("couldn't read config: %v", err)
+	}
+	if !strings.Contains(string(data), "# my open-source token") {
+		t.Errorf("profile commands lost a comment:\n%s", data)
+	}
+}
+
+func TestCheckProfiles(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{name: "missing current profile", data: "current_profile: oss\n", want: `current_profile "oss"`},
+		{name: "nested profiles", data: "profiles:\n    oss:\n        current_profile: work\n", want: "profile oss can't set"},
+		{name: "invalid profile value", data: "profiles:\n    oss:\n        per_page: 500\n", want: "profile oss: per_page"},
+		{name: "profile named default", data: "profiles:\n    default:\n        me: 8\n", want: "can't be named default"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Parse() error = %v, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// AllProjects is the projects key whose usernames apply to every project.
+const AllProjects = "all"
+
+// ProjectPattern splits a wildcard projects key into the namespace it matches. `platform/*` is every
+// project in the platform group and `platform/**` includes its subgroups' projects too.
+func ProjectPattern(key string) (namespace string, includeSubgroups bool, ok bool) {
+	if namespace, ok := strings.CutSuffix(key, "/**"); ok {
+		return namespace, true, validNamespace(namespace)
+	}
+	if namespace, ok := strings.CutSuffix(key, "/*"); ok {
+		return namespace, false, validNamespace(namespace)
+	}
+	return "", false, false
+}
+
+// validNamespace reports whether namespace looks like a group path, e.g. platform or platform/backend.
+func validNamespace(namespace string) bool {
+	return namespace != "" && !strings.Contains(namespace, "*") && !strings.HasPrefix(namespace, "/") && !strings.Contains(namespace, "//")
+}
+
+// checkProjects reports projects keys that aren't an ID, a path like platform/api or a wildcard like platform/*.
+func (config *Config) checkProjects() []error {
+	keys := make([]string, 0, len(config.Projects))
+	for key := range config.Projects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var errs []error
+	for _, key := range keys {
+		if key == AllProjects {
+			continue
+		}
+		if _, err := strconv.Atoi(key); err == nil {
+			continue
+		}
+		if strings.Contains(key, "*") {
+			if _, _, ok := ProjectPattern(key); !ok {
+				errs = append(errs, fmt.Errorf("projects key %q isn't a wildcard like platform/* or platform/**", key))
+			}
+			continue
+		}
+		if !strings.Contains(key, "/") || strings.HasPrefix(key, "/") || strings.HasSuffix(key, "/") {
+			errs = append(errs, fmt.Errorf("projects key %q isn't a project ID or a path like platform/api", key))
+		}
+	}
+	return errs
+}
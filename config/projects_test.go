@@ -0,0 +1,51 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestProjectPattern(t *testing.T) {
+	tests := []struct {
+		key                  string
+		wantNamespace        string
+		wantIncludeSubgroups bool
+		wantOk               bool
+	}{
+		{key: "platform/*", wantNamespace: "platform", wantOk: true},
+		{key: "platform/backend/**", wantNamespace: "platform/backend", wantIncludeSubgroups: true, wantOk: true},
+		{key: "platform/api"},
+		{key: "123"},
+		{key: "/*"},
+		{key: "*/api/*"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			namespace, includeSubgroups, ok := ProjectPattern(tt.key)
+			if ok != tt.wantOk || ok && (namespace != tt.wantNamespace || includeSubgroups != tt.wantIncludeSubgroups) {
+				t.Errorf("ProjectPattern(%q) = %q, %v, %v, want %q, %v, %v", tt.key, namespace, includeSubgroups, ok, tt.wantNamespace, tt.wantIncludeSubgroups, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestCheckProjects(t *testing.T) {
+	config := Config{Projects: map[string][]string{
+		"all":          {"erin"},
+		"123":          {"alice"},
+		"platform/api": {"bob"},
+		"platform/*":   {"carol"},
+		"platform/**":  {"dave"},
+		"api":          {"mia"},
+		"platform/a*":  {"mia"},
+	}}
+
+	errs := config.checkProjects()
+	if len(errs) != 2 {
+		t.Fatalf("checkProjects() = %v, want errors for api and platform/a*", errs)
+	}
+	if !strings.Contains(errs[0].Error(), `"api"`) || !strings.Contains(errs[1].Error(), `"platform/a*"`) {
+		t.Errorf("checkProjects() = %v, want errors for api and platform/a*", errs)
+	}
+}
@@ -0,0 +1,518 @@
+// Package fakegitlab serves a small, in-memory imitation of the GitLab merge request API
+// so the rest of macglab can be tested without talking to a real GitLab instance.
+//
+// It only implements what macglab uses: listing group and project merge requests,
+// filtered by state, creation and last update times, author, reviewer, approver and draft status,
+// with offset pagination, getting a single merge request, its approvals and its notes,
+// looking up users among the fixtures' authors and reviewers, and getting and listing
+// the fixtures' projects.
+package fakegitlab
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// defaultPerPage matches GitLab's default page size.
+const defaultPerPage = 20
+
+// Fixture is a merge request as GitLab would return it, plus the facts the fake needs
+// to answer queries that GitLab answers from data it doesn't include in the response.
+type Fixture struct {
+	gitlab.MergeRequest
+	// GroupId is the group the merge request's project belongs to.
+	GroupId string `json:"group_id"`
+	// ApprovedByIds lists the user IDs that approved the merge request.
+	ApprovedByIds []int `json:"approved_by_ids"`
+	// ApprovalsRequired is how many approvals the merge request needs.
+	ApprovalsRequired int `json:"approvals_required"`
+	// Notes are the merge request's comments and system notes, oldest first.
+	Notes []gitlab.Note `json:"notes"`
+}
+
+// DefaultUserId is the ID of the user the fake's access token belongs to.
+const DefaultUserId = 7
+
+// Server is a fake GitLab API backed by fixtures.
+type Server struct {
+	*httptest.Server
+
+	// UserId is the ID of the user approvals and comments are made as.
+	UserId int
+
+	mu       sync.Mutex
+	fixtures []Fixture
+	requests []string
+}
+
+// LoadFixtures reads a JSON array of fixtures from a file.
+func LoadFixtures(fixturesUrl string) ([]Fixture, error) {
+	data, err := os.ReadFile(fixturesUrl)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read %s: %w", fixturesUrl, err)
+	}
+
+	var fixtures []Fixture
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		return nil, fmt.Errorf("couldn't unmarshal %s: %w", fixturesUrl, err)
+	}
+
+	return fixtures, nil
+}
+
+// NewServer starts a fake GitLab serving the given fixtures. Call Close when done.
+func NewServer(fixtures []Fixture) *Server {
+	server := &Server{UserId: DefaultUserId, fixtures: fixtures}
+	server.Server = httptest.NewServer(http.HandlerFunc(server.handle))
+	return server
+}
+
+// Client returns a GitLab client pointed at the fake.
+func (server *Server) Client() (*gitlab.Client, error) {
+	return gitlab.NewClient("fake-token", gitlab.WithBaseURL(server.URL), gitlab.WithoutRetries())
+}
+
+// Fixtures returns the fixtures as they are now, including approvals and notes added through the API.
+func (server *Server) Fixtures() []Fixture {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	return append([]Fixture{}, server.fixtures...)
+}
+
+// Requests returns the request URIs the fake has served, in order.
+func (server *Server) Requests() []string {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	return append([]string{}, server.requests...)
+}
+
+func (server *Server) handle(w http.ResponseWriter, r *http.Request) {
+	// Serve one request at a time so approvals and comments never race with reads.
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	server.requests = append(server.requests, r.URL.RequestURI())
+
+	// Use the escaped path so URL-encoded project paths stay in one segment.
+	segments := strings.Split(strings.TrimPrefix(r.URL.EscapedPath(), "/api/v4/"), "/")
+	if r.Method == http.MethodGet && len(segments) == 1 && (segments[0] == "user" || segments[0] == "users") {
+		server.handleUsers(w, r, segments[0])
+		return
+	}
+	if r.Method == http.MethodGet && (len(segments) == 2 && segments[0] == "projects" || len(segments) == 3 && segments[0] == "groups" && segments[2] == "projects") {
+		server.handleProjects(w, r, segments)
+		return
+	}
+	if len(segments) < 3 || segments[2] != "merge_requests" {
+		writeError(w, http.StatusNotFound, "404 Not Found")
+		return
+	}
+
+	id, err := url.PathUnescape(segments[1])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "400 Bad Request")
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		server.handlePost(w, r, segments, id)
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "405 Method Not Allowed")
+		return
+	}
+
+	switch {
+	case len(segments) == 3 && segments[0] == "groups":
+		server.listMergeRequests(w, r, func(fixture Fixture) bool { return fixture.GroupId == id })
+	case len(segments) == 3 && segments[0] == "projects":
+		server.listMergeRequests(w, r, func(fixture Fixture) bool { return matchesProject(fixture, id) })
+	case len(segments) == 4 && segments[0] == "projects":
+		if fixture := server.findMergeRequest(id, segments[3]); fixture != nil {
+			writeJson(w, fixture.MergeRequest)
+			return
+		}
+		writeError(w, http.StatusNotFound, "404 Not Found")
+	case len(segments) == 5 && segments[0] == "projects" && segments[4] == "approvals":
+		if fixture := server.findMergeRequest(id, segments[3]); fixture != nil {
+			writeJson(w, server.approvals(*fixture))
+			return
+		}
+		writeError(w, http.StatusNotFound, "404 Not Found")
+	case len(segments) == 5 && segments[0] == "projects" && segments[4] == "notes":
+		if fixture := server.findMergeRequest(id, segments[3]); fixture != nil {
+			writePage(w, r.URL.Query(), fixture.Notes)
+			return
+		}
+		writeError(w, http.StatusNotFound, "404 Not Found")
+	default:
+		writeError(w, http.StatusNotFound, "404 Not Found")
+	}
+}
+
+// handlePost approves, unapproves and comments on merge requests as the server's user.
+func (server *Server) handlePost(w http.ResponseWriter, r *http.Request, segments []string, projectId string) {
+	if len(segments) != 5 || segments[0] != "projects" {
+		writeError(w, http.StatusNotFound, "404 Not Found")
+		return
+	}
+
+	fixture := server.findMergeRequest(projectId, segments[3])
+	if fixture == nil {
+		writeError(w, http.StatusNotFound, "404 Not Found")
+		return
+	}
+
+	var body struct {
+		Sha  string `json:"sha"`
+		Body string `json:"body"`
+	}
+	if r.Body != nil {
+		json.NewDecoder(r.Body).Decode(&body)
+	}
+
+	switch segments[4] {
+	case "approve":
+		if body.Sha != "" && body.Sha != fixture.SHA {
+			writeError(w, http.StatusConflict, "SHA does not match HEAD of source branch")
+			return
+		}
+		if fixture.Author != nil && fixture.Author.ID == server.UserId {
+			writeError(w, http.StatusUnauthorized, "401 Unauthorized")
+			return
+		}
+		for _, approvedById := range fixture.ApprovedByIds {
+			if approvedById == server.UserId {
+				writeError(w, http.StatusUnauthorized, "401 Unauthorized")
+				return
+			}
+		}
+		fixture.ApprovedByIds = append(fixture.ApprovedByIds, server.UserId)
+		server.addNote(fixture, true, "approved this merge request")
+		writeJsonStatus(w, http.StatusCreated, server.approvals(*fixture))
+	case "unapprove":
+		for i, approvedById := range fixture.ApprovedByIds {
+			if approvedById == server.UserId {
+				fixture.ApprovedByIds = append(fixture.ApprovedByIds[:i:i], fixture.ApprovedByIds[i+1:]...)
+				server.addNote(fixture, true, "unapproved this merge request")
+				w.WriteHeader(http.StatusCreated)
+				return
+			}
+		}
+		writeError(w, http.StatusNotFound, "404 Not Found")
+	case "notes":
+		if body.Body == "" {
+			writeError(w, http.StatusBadRequest, "body is missing")
+			return
+		}
+		note := server.addNote(fixture, false, body.Body)
+		writeJsonStatus(w, http.StatusCreated, note)
+	default:
+		writeError(w, http.StatusNotFound, "404 Not Found")
+	}
+}
+
+func (server *Server) addNote(fixture *Fixture, system bool, body string) gitlab.Note {
+	now := time.Now()
+	note := gitlab.Note{ID: len(fixture.Notes) + 1, Body: body, System: system, CreatedAt: &now, UpdatedAt: &now}
+	note.Author.ID = server.UserId
+	if user := server.findUser(server.UserId); user != nil {
+		note.Author.Username = user.Username
+	}
+	fixture.Notes = append(fixture.Notes, note)
+	return note
+}
+
+func (server *Server) listMergeRequests(w http.ResponseWriter, r *http.Request, matches func(fixture Fixture) bool) {
+	query := r.URL.Query()
+	var mergeRequests []gitlab.MergeRequest
+	for _, fixture := range server.fixtures {
+		if matches(fixture) && matchesQuery(fixture, query) {
+			mergeRequest := fixture.MergeRequest
+			// Like GitLab, only single merge request responses include the head pipeline.
+			mergeRequest.HeadPipeline = nil
+			mergeRequests = append(mergeRequests, mergeRequest)
+		}
+	}
+
+	writePage(w, query, mergeRequests)
+}
+
+// findMergeRequest finds a merge request by its project's ID or path and its IID.
+func (server *Server) findMergeRequest(projectId string, iid string) *Fixture {
+	for i := range server.fixtures {
+		if matchesProject(server.fixtures[i], projectId) && strconv.Itoa(server.fixtures[i].IID) == iid {
+			return &server.fixtures[i]
+		}
+	}
+	return nil
+}
+
+// matchesProject reports whether a fixture belongs to the project with the given ID or path.
+func matchesProject(fixture Fixture, projectId string) bool {
+	if strconv.Itoa(fixture.ProjectID) == projectId {
+		return true
+	}
+	projectPath, ok := fixtureProjectPath(fixture)
+	return ok && projectPath == projectId
+}
+
+// fixtureProjectPath reads the path of a fixture's project from its web URL, e.g. acme/project-1.
+func fixtureProjectPath(fixture Fixture) (string, bool) {
+	projectPath, _, ok := strings.Cut(strings.TrimPrefix(fixture.WebURL, "https://"), "/-/")
+	if !ok {
+		return "", false
+	}
+	_, projectPath, _ = strings.Cut(projectPath, "/")
+	return projectPath, true
+}
+
+// handleProjects answers GET /projects/:id with a fixture's project, and GET /groups/:id/projects
+// with the fixtures' projects in the group, or in its subgroups too with include_subgroups=true.
+func (server *Server) handleProjects(w http.ResponseWriter, r *http.Request, segments []string) {
+	id, err := url.PathUnescape(segments[1])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "400 Bad Request")
+		return
+	}
+
+	if segments[0] == "projects" {
+		for _, project := range server.projects() {
+			if strconv.Itoa(project.ID) == id || project.PathWithNamespace == id {
+				writeJson(w, project)
+				return
+			}
+		}
+		writeError(w, http.StatusNotFound, "404 Project Not Found")
+		return
+	}
+
+	includeSubgroups := r.URL.Query().Get("include_subgroups") == "true"
+	groupProjects := []gitlab.Project{}
+	for _, project := range server.projects() {
+		namespace := path.Dir(project.PathWithNamespace)
+		if namespace == id || includeSubgroups && strings.HasPrefix(namespace, id+"/") {
+			groupProjects = append(groupProjects, project)
+		}
+	}
+	if len(groupProjects) == 0 {
+		writeError(w, http.StatusNotFound, "404 Group Not Found")
+		return
+	}
+	writePage(w, r.URL.Query(), groupProjects)
+}
+
+// projects lists the fixtures' projects in order of ID.
+func (server *Server) projects() []gitlab.Project {
+	projects := []gitlab.Project{}
+	seen := map[int]bool{}
+	for _, fixture := range server.fixtures {
+		projectPath, ok := fixtureProjectPath(fixture)
+		if !ok || seen[fixture.ProjectID] {
+			continue
+		}
+		seen[fixture.ProjectID] = true
+		projects = append(projects, gitlab.Project{
+			ID:                fixture.ProjectID,
+			Path:              path.Base(projectPath),
+			PathWithNamespace: projectPath,
+		})
+	}
+	sort.Slice(projects, func(i, j int) bool { return projects[i].ID < projects[j].ID })
+	return projects
+}
+
+// handleUsers answers GET /user with the server's user and GET /users?username= with matching users.
+func (server *Server) handleUsers(w http.ResponseWriter, r *http.Request, endpoint string) {
+	if endpoint == "user" {
+		user := server.findUser(server.UserId)
+		writeJson(w, gitlab.User{ID: user.ID, Username: user.Username, Name: user.Name})
+		return
+	}
+
+	username := r.URL.Query().Get("username")
+	matches := []gitlab.User{}
+	for _, fixture := range server.fixtures {
+		users := append([]*gitlab.BasicUser{fixture.Author}, fixture.Reviewers...)
+		for _, user := range users {
+			if user != nil && user.Username != "" && strings.EqualFold(user.Username, username) {
+				matches = append(matches, gitlab.User{ID: user.ID, Username: user.Username, Name: user.Name})
+				break
+			}
+		}
+		if len(matches) > 0 {
+			break
+		}
+	}
+	writeJson(w, matches)
+}
+
+// findUser looks up a user by ID among the fixtures' authors and reviewers.
+func (server *Server) findUser(userId int) *gitlab.BasicUser {
+	for _, fixture := range server.fixtures {
+		users := append([]*gitlab.BasicUser{fixture.Author}, fixture.Reviewers...)
+		for _, user := range users {
+			if user != nil && user.ID == userId {
+				return user
+			}
+		}
+	}
+	return &gitlab.BasicUser{ID: userId}
+}
+
+// approvals builds a merge request's approval state from its fixture.
+func (server *Server) approvals(fixture Fixture) gitlab.MergeRequestApprovals {
+	mergeRequestApprovals := gitlab.MergeRequestApprovals{
+		ID:                fixture.ID,
+		IID:               fixture.IID,
+		ProjectID:         fixture.ProjectID,
+		ApprovalsRequired: fixture.ApprovalsRequired,
+		ApprovedBy:        []*gitlab.MergeRequestApproverUser{},
+	}
+	for _, approvedById := range fixture.ApprovedByIds {
+		mergeRequestApprovals.ApprovedBy = append(mergeRequestApprovals.ApprovedBy, &gitlab.MergeRequestApproverUser{
+			User: server.findUser(approvedById),
+		})
+	}
+	mergeRequestApprovals.ApprovalsLeft = fixture.ApprovalsRequired - len(fixture.ApprovedByIds)
+	if mergeRequestApprovals.ApprovalsLeft < 0 {
+		mergeRequestApprovals.ApprovalsLeft = 0
+	}
+	mergeRequestApprovals.Approved = mergeRequestApprovals.ApprovalsLeft == 0
+	return mergeRequestApprovals
+}
+
+// matchesQuery applies the merge request list filters macglab uses.
+func matchesQuery(fixture Fixture, query url.Values) bool {
+	if state := query.Get("state"); state != "" && state != "all" && fixture.State != state {
+		return false
+	}
+
+	if updatedAfter := query.Get("updated_after"); updatedAfter != "" {
+		after, err := time.Parse(time.RFC3339, updatedAfter)
+		if err != nil || fixture.UpdatedAt == nil || fixture.UpdatedAt.Before(after) {
+			return false
+		}
+	}
+
+	if createdAfter := query.Get("created_after"); createdAfter != "" {
+		after, err := time.Parse(time.RFC3339, createdAfter)
+		if err != nil || fixture.CreatedAt == nil || fixture.CreatedAt.Before(after) {
+			return false
+		}
+	}
+
+	if createdBefore := query.Get("created_before"); createdBefore != "" {
+		before, err := time.Parse(time.RFC3339, createdBefore)
+		if err != nil || fixture.CreatedAt == nil || !fixture.CreatedAt.Before(before) {
+			return false
+		}
+	}
+
+	if authorUsername := query.Get("author_username"); authorUsername != "" {
+		if fixture.Author == nil || fixture.Author.Username != authorUsername {
+			return false
+		}
+	}
+
+	if reviewerId := query.Get("reviewer_id"); reviewerId != "" {
+		isReviewer := false
+		for _, reviewer := range fixture.Reviewers {
+			if strconv.Itoa(reviewer.ID) == reviewerId {
+				isReviewer = true
+				break
+			}
+		}
+		if !isReviewer {
+			return false
+		}
+	}
+
+	for _, approverId := range query["approved_by_ids[]"] {
+		isApprover := false
+		for _, approvedById := range fixture.ApprovedByIds {
+			if strconv.Itoa(approvedById) == approverId {
+				isApprover = true
+				break
+			}
+		}
+		if !isApprover {
+			return false
+		}
+	}
+
+	switch query.Get("wip") {
+	case "yes":
+		return fixture.Draft
+	case "no":
+		return !fixture.Draft
+	}
+
+	return true
+}
+
+// writePage writes one page of results with GitLab's pagination headers.
+func writePage[T any](w http.ResponseWriter, query url.Values, results []T) {
+	page, _ := strconv.Atoi(query.Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	perPage, _ := strconv.Atoi(query.Get("per_page"))
+	if perPage < 1 {
+		perPage = defaultPerPage
+	}
+
+	totalPages := (len(results) + perPage - 1) / perPage
+	start := (page - 1) * perPage
+	end := start + perPage
+	if start > len(results) {
+		start = len(results)
+	}
+	if end > len(results) {
+		end = len(results)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Page", strconv.Itoa(page))
+	w.Header().Set("X-Per-Page", strconv.Itoa(perPage))
+	w.Header().Set("X-Total", strconv.Itoa(len(results)))
+	w.Header().Set("X-Total-Pages", strconv.Itoa(totalPages))
+	if page < totalPages {
+		w.Header().Set("X-Next-Page", strconv.Itoa(page+1))
+	}
+
+	body := results[start:end]
+	if body == nil {
+		body = []T{}
+	}
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeJson(w http.ResponseWriter, body interface{}) {
+	writeJsonStatus(w, http.StatusOK, body)
+}
+
+func writeJsonStatus(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"message": message})
+}
@@ -8,8 +8,12 @@ import (
 
 var (
 	HomeUri          string
+	MacglabCacheUri  string
 	MacglabConfigUrl string
+	MacglabNotifiedUrl string
+	MacglabSnoozedUrl string
 	MacglabUri       string
+	MacglabUsersUrl  string
 	MacglabZshConfigUrl string
 	ShConfigUrl      string
 	SampleConfigUrl  = "config.sample.yml"
@@ -29,6 +33,10 @@ func init() {
 	ShConfigUrl = fmt.Sprintf("%s/.zshrc", HomeUri)
 	MacglabUri = fmt.Sprintf("%s/.macglab", HomeUri)
 	MacglabConfigUrl = fmt.Sprintf("%s/config.yml", MacglabUri)
+	MacglabCacheUri = fmt.Sprintf("%s/cache", MacglabUri)
+	MacglabNotifiedUrl = fmt.Sprintf("%s/notified.json", MacglabUri)
+	MacglabSnoozedUrl = fmt.Sprintf("%s/snoozed.json", MacglabUri)
+	MacglabUsersUrl = fmt.Sprintf("%s/users.json", MacglabUri)
 	MacglabZshConfigUrl = fmt.Sprintf("%s/macglab.zsh", MacglabUri)
 }
 
@@ -0,0 +1,22 @@
+package flags
+
+import "github.com/spf13/cobra"
+
+// GlobalFlags apply to every command.
+type GlobalFlags struct {
+	Profile string
+}
+
+var globalFlags = GlobalFlags{
+	Profile: "",
+}
+
+// AddGlobalFlags adds the flags every command accepts.
+func AddGlobalFlags(rootCmd *cobra.Command) {
+	rootFlags := rootCmd.PersistentFlags()
+	rootFlags.StringVar(&globalFlags.Profile, "profile", "", "Use the named profile from your config instead of the current one.")
+}
+
+func GetGlobalFlags() GlobalFlags {
+	return globalFlags
+}
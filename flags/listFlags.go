@@ -4,6 +4,7 @@ import (
 	"strings"
 
 	"github.com/mjburtenshaw/macglab/config"
+	"github.com/mjburtenshaw/macglab/mrs"
 	"github.com/spf13/cobra"
 )
 
@@ -13,28 +14,50 @@ type BooleanFlags struct {
 	Count	 bool
 	Draft    bool
 	Group    bool
+	NoCache  bool
 	Projects bool
 	Ready    bool
+	Refresh  bool
+	ShowSnoozed bool
 }
 
 type ResolvedFlags struct {
 	AccessToken string
+	BaseUrl     string
+	Concurrency int
 	GroupId     string
-	Me          int
-	Usernames   []string
+	// Me is your user ID, resolved from MeUser once connected to GitLab.
+	Me int
+	// MeUser is you as a username or user ID. Empty means whoever the access token belongs to.
+	MeUser    string
+	Usernames []string
+}
+
+// DisplayFlags control how results are rendered. They don't override config.
+type DisplayFlags struct {
+	ColumnsRaw string
+	Format     string
+	GroupBy    string
+	NoHeader   bool
+	Output     string
+	Reverse    bool
+	Sort       string
 }
 
 type TrueUpFlags map[string]bool
 
 type RawValueFlags struct {
 	AccessToken  string
+	BaseUrl      string
+	Concurrency  int
 	GroupId      string
-	Me           int
+	Me           string
 	UsernamesRaw string
 }
 
 type ListFlags struct {
 	Boolean  BooleanFlags
+	Display  DisplayFlags
 	RawValue RawValueFlags
 	Resolved ResolvedFlags
 	TrueUp   TrueUpFlags
@@ -46,36 +69,65 @@ var booleanFlags = BooleanFlags{
 	Count:    false,
 	Draft:    false,
 	Group:    false,
+	NoCache:  false,
 	Projects: false,
 	Ready:    false,
+	Refresh:  false,
+	ShowSnoozed: false,
+}
+
+var displayFlags = DisplayFlags{
+	Output: mrs.OutputText,
 }
 
 var valueFlags = RawValueFlags{
 	AccessToken:  "",
+	BaseUrl:      "",
+	Concurrency:  0,
 	GroupId:      "",
-	Me:           0,
+	Me:           "",
 	UsernamesRaw: "",
 }
 
 func AddListFlags(listCmd *cobra.Command) {
+	AddQueryFlags(listCmd)
+
 	listFlags := listCmd.PersistentFlags()
-	listFlags.BoolVarP(&booleanFlags.Approved, "approved", "a", false, "Include MRs you approved.")
 	listFlags.BoolVarP(&booleanFlags.Browser, "browser", "b", false, "Open MRs in the browser.")
 	listFlags.BoolVarP(&booleanFlags.Count, "count", "c", false, "Print the result count to the terminal.")
-	listFlags.BoolVarP(&booleanFlags.Draft, "draft", "d", false, "Include draft MRs.")
-	listFlags.BoolVarP(&booleanFlags.Group, "group", "g", false, "ONLY include MRs where the author is listed in the provided users (*see -u, --users*) or the configured usernames.")
-	listFlags.BoolVarP(&booleanFlags.Projects, "projects", "p", false, "ONLY include MRs where the author is listed in ANY of the configured projects; but it only returns MRs for projects the author is listed under.")
-	listFlags.BoolVarP(&booleanFlags.Ready, "ready", "r", false, "Include mergeable MRs.")
-	listFlags.StringVarP(&valueFlags.GroupId, "group-id", "i", "", "Override the configured groud ID.")
-	listFlags.IntVarP(&valueFlags.Me, "me", "m", 0, "Override the configured me user ID with the given number.")
-	listFlags.StringVarP(&valueFlags.AccessToken, "access-token", "t", "", "Override the configured access token.")
-	listFlags.StringVarP(&valueFlags.UsernamesRaw, "users", "u", "", "Override configured usernames and ONLY filter on usernames you provided. Accepts a CSV of usernames.")
+	listFlags.BoolVar(&displayFlags.NoHeader, "no-header", false, "Omit the header row from --output table.")
+	listFlags.StringVarP(&displayFlags.Output, "output", "o", mrs.OutputText, "Print MRs in the given format: "+strings.Join(mrs.OutputFormats, ", ")+".")
+	listFlags.StringVar(&displayFlags.ColumnsRaw, "columns", "", "Choose and order the columns printed by --output table. Accepts a CSV of: "+strings.Join(mrs.DefaultColumns, ", ")+".")
+	listFlags.StringVarP(&displayFlags.Format, "format", "f", "", "Print each MR with the given Go template, or @name for one of the configured templates.")
+	listFlags.StringVar(&displayFlags.GroupBy, "group-by", "", "Print MRs in sections by "+strings.Join(mrs.GroupByKeys, ", ")+". Use with -c, --count for per-section counts.")
+	listFlags.BoolVar(&displayFlags.Reverse, "reverse", false, "Reverse the order given by --sort.")
+	listFlags.StringVarP(&displayFlags.Sort, "sort", "s", "", "Sort MRs by "+strings.Join(mrs.SortKeys, ", ")+".")
+}
+
+// AddQueryFlags adds the flags that choose which MRs list fetches, for commands that reuse its query.
+func AddQueryFlags(queryCmd *cobra.Command) {
+	queryFlags := queryCmd.PersistentFlags()
+	queryFlags.BoolVarP(&booleanFlags.Approved, "approved", "a", false, "Include MRs you approved.")
+	queryFlags.BoolVarP(&booleanFlags.Draft, "draft", "d", false, "Include draft MRs.")
+	queryFlags.BoolVarP(&booleanFlags.Group, "group", "g", false, "ONLY include MRs where the author is listed in the provided users (*see -u, --users*) or the configured usernames.")
+	queryFlags.BoolVarP(&booleanFlags.Projects, "projects", "p", false, "ONLY include MRs where the author is listed in ANY of the configured projects; but it only returns MRs for projects the author is listed under.")
+	queryFlags.BoolVar(&booleanFlags.NoCache, "no-cache", false, "Don't read or write cached GitLab responses.")
+	queryFlags.BoolVarP(&booleanFlags.Ready, "ready", "r", false, "Include mergeable MRs.")
+	queryFlags.BoolVar(&booleanFlags.Refresh, "refresh", false, "Ask GitLab for fresh results, then update the cache.")
+	queryFlags.BoolVar(&booleanFlags.ShowSnoozed, "show-snoozed", false, "Include MRs you snoozed or ignored.")
+	queryFlags.StringVar(&valueFlags.BaseUrl, "base-url", "", "Override the configured GitLab base URL.")
+	queryFlags.IntVar(&valueFlags.Concurrency, "concurrency", 0, "Override the configured number of GitLab requests to run at once.")
+	queryFlags.StringVarP(&valueFlags.GroupId, "group-id", "i", "", "Override the configured groud ID.")
+	queryFlags.StringVarP(&valueFlags.Me, "me", "m", "", "Override the configured me with the given username or user ID.")
+	queryFlags.StringVarP(&valueFlags.AccessToken, "access-token", "t", "", "Override the configured access token.")
+	queryFlags.StringVarP(&valueFlags.UsernamesRaw, "users", "u", "", "Override configured usernames and ONLY filter on usernames you provided. Accepts a CSV of usernames.")
 }
 
 func GetListFlags(conf *config.Config) (listFlags ListFlags) {
 	resolvedFlags, trueUpFlags := resolveListFlags(conf)
 	listFlags = ListFlags{
 		Boolean:  booleanFlags,
+		Display:  displayFlags,
 		RawValue: valueFlags,
 		Resolved: resolvedFlags,
 		TrueUp:   trueUpFlags,
@@ -83,11 +135,15 @@ func GetListFlags(conf *config.Config) (listFlags ListFlags) {
 	return listFlags
 }
 
+// resolveListFlags resolves each value from, in order of precedence, its flag, its MACGLAB_* environment
+// variable and the config file. conf already has the environment applied over the file; see config.ReadProfile.
 func resolveListFlags(conf *config.Config) (resolvedFlags ResolvedFlags, trueUpFlags TrueUpFlags) {
 	resolvedFlags = ResolvedFlags{
 		AccessToken: conf.AccessToken,
+		BaseUrl:     conf.BaseUrl,
+		Concurrency: conf.Concurrency,
 		GroupId:     conf.GroupId,
-		Me:          conf.Me,
+		MeUser:      conf.Me,
 		Usernames:   []string{},
 	}
 
@@ -98,13 +154,22 @@ func resolveListFlags(conf *config.Config) (resolvedFlags ResolvedFlags, trueUpF
 		trueUpFlags["shouldAskToUpdateAccessToken"] = true
 	}
 
+	if valueFlags.BaseUrl != "" {
+		resolvedFlags.BaseUrl = valueFlags.BaseUrl
+		trueUpFlags["shouldAskToUpdateBaseUrl"] = true
+	}
+
+	if valueFlags.Concurrency > 0 {
+		resolvedFlags.Concurrency = valueFlags.Concurrency
+	}
+
 	if valueFlags.GroupId != "" {
 		resolvedFlags.GroupId = valueFlags.GroupId
 		trueUpFlags["shouldAskToUpdateGroupId"] = true
 	}
 
-	if valueFlags.Me != 0 {
-		resolvedFlags.Me = valueFlags.Me
+	if valueFlags.Me != "" {
+		resolvedFlags.MeUser = valueFlags.Me
 		trueUpFlags["shouldAskToUpdateMe"] = true
 	}
 
@@ -1,11 +1,88 @@
 package glab
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/mjburtenshaw/macglab/cache"
 	"github.com/xanzy/go-gitlab"
 )
 
 type TGitlabClient = gitlab.Client
 
-func Initialize(accessToken string) (*TGitlabClient, error) {
-	return gitlab.NewClient(accessToken)
+// ClientOptions configures which GitLab instance we talk to and how.
+type ClientOptions struct {
+	// BaseUrl points the client at a self-managed instance. Empty means gitlab.com.
+	BaseUrl string
+	// CaFile is a PEM bundle trusted in addition to the system certificate pool.
+	CaFile string
+	// InsecureSkipVerify disables TLS certificate verification. Only use this for testing.
+	InsecureSkipVerify bool
+	// CacheDir is where responses are cached. Empty means don't cache.
+	CacheDir string
+	// CacheTTL is how long a cached response is served without asking GitLab.
+	CacheTTL time.Duration
+	// RefreshCache ignores fresh cached responses, but still updates the cache.
+	RefreshCache bool
+}
+
+func Initialize(accessToken string, clientOptions ClientOptions) (*TGitlabClient, error) {
+	var options []gitlab.ClientOptionFunc
+
+	if clientOptions.BaseUrl != "" {
+		options = append(options, gitlab.WithBaseURL(clientOptions.BaseUrl))
+	}
+
+	if clientOptions.CaFile != "" || clientOptions.InsecureSkipVerify || clientOptions.CacheDir != "" {
+		httpClient, err := newHttpClient(clientOptions)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, gitlab.WithHTTPClient(httpClient))
+	}
+
+	return gitlab.NewClient(accessToken, options...)
+}
+
+// newHttpClient builds an HTTP client trusting the configured CA file and caching responses.
+func newHttpClient(clientOptions ClientOptions) (*http.Client, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: clientOptions.InsecureSkipVerify,
+	}
+
+	if clientOptions.CaFile != "" {
+		caCerts, err := os.ReadFile(clientOptions.CaFile)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't read CA file %s: %w", clientOptions.CaFile, err)
+		}
+
+		certPool, err := x509.SystemCertPool()
+		if err != nil {
+			certPool = x509.NewCertPool()
+		}
+		if ok := certPool.AppendCertsFromPEM(caCerts); !ok {
+			return nil, fmt.Errorf("couldn't find any PEM certificates in CA file %s", clientOptions.CaFile)
+		}
+		tlsConfig.RootCAs = certPool
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	if clientOptions.CacheDir == "" {
+		return &http.Client{Transport: transport}, nil
+	}
+
+	return &http.Client{
+		Transport: &cache.Transport{
+			Base:    transport,
+			Dir:     clientOptions.CacheDir,
+			TTL:     clientOptions.CacheTTL,
+			Refresh: clientOptions.RefreshCache,
+		},
+	}, nil
 }
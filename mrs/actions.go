@@ -0,0 +1,64 @@
+package mrs
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// ErrShaMismatch is returned when approving a merge request pinned to a SHA that's no longer its head.
+var ErrShaMismatch = errors.New("the merge request has new commits")
+
+// MergeRequestActions changes merge requests on behalf of the user.
+type MergeRequestActions interface {
+	// ApproveMergeRequest approves a merge request. If sha isn't empty, GitLab only approves
+	// the merge request if sha is still its head.
+	ApproveMergeRequest(ref Reference, sha string) error
+	// UnapproveMergeRequest withdraws the user's approval of a merge request.
+	UnapproveMergeRequest(ref Reference) error
+	// CommentOnMergeRequest adds a comment to a merge request.
+	CommentOnMergeRequest(ref Reference, body string) error
+}
+
+var _ MergeRequestActions = (*GitlabSource)(nil)
+
+// ApproveMergeRequest implements MergeRequestActions.
+func (source *GitlabSource) ApproveMergeRequest(ref Reference, sha string) error {
+	options := &gitlab.ApproveMergeRequestOptions{}
+	if sha != "" {
+		options.SHA = gitlab.String(sha)
+	}
+
+	_, response, err := source.client.MergeRequestApprovals.ApproveMergeRequest(ref.Project, ref.IID, options)
+	if err != nil {
+		if sha != "" && response != nil && response.StatusCode == http.StatusConflict {
+			return fmt.Errorf("couldn't approve %s: %w since %s", ref, ErrShaMismatch, sha)
+		}
+		return fmt.Errorf("couldn't approve %s: %w", ref, err)
+	}
+
+	return nil
+}
+
+// UnapproveMergeRequest implements MergeRequestActions.
+func (source *GitlabSource) UnapproveMergeRequest(ref Reference) error {
+	if _, err := source.client.MergeRequestApprovals.UnapproveMergeRequest(ref.Project, ref.IID); err != nil {
+		return fmt.Errorf("couldn't unapprove %s: %w", ref, err)
+	}
+
+	return nil
+}
+
+// CommentOnMergeRequest implements MergeRequestActions.
+func (source *GitlabSource) CommentOnMergeRequest(ref Reference, body string) error {
+	_, _, err := source.client.Notes.CreateMergeRequestNote(ref.Project, ref.IID, &gitlab.CreateMergeRequestNoteOptions{
+		Body: gitlab.String(body),
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't comment on %s: %w", ref, err)
+	}
+
+	return nil
+}
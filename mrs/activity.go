@@ -0,0 +1,148 @@
+package mrs
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// approvedNote is the body of the system note GitLab adds when someone approves a merge request.
+const approvedNote = "approved this merge request"
+
+// ReviewActivity is when and by whom a merge request was reviewed.
+type ReviewActivity struct {
+	// FirstReviewAt is when someone other than the author first commented on or approved the merge request.
+	FirstReviewAt *time.Time
+	// ApprovedAt is when the merge request was first approved.
+	ApprovedAt *time.Time
+	// Approvers are the usernames of users who approved the merge request.
+	Approvers []string
+}
+
+// FetchGroupMergeRequestsCreatedBetween fetches merge requests a user authored within a group,
+// in any state, that were created in [after, before).
+func (source *GitlabSource) FetchGroupMergeRequestsCreatedBetween(groupId string, username string, after time.Time, before time.Time) ([]*gitlab.MergeRequest, error) {
+	userMrs, err := fetchAllPages(func(listOptions gitlab.ListOptions, requestOptions ...gitlab.RequestOptionFunc) ([]*gitlab.MergeRequest, *gitlab.Response, error) {
+		return source.client.MergeRequests.ListGroupMergeRequests(groupId, &gitlab.ListGroupMergeRequestsOptions{
+			ListOptions:    listOptions,
+			AuthorUsername: gitlab.String(username),
+			State:          gitlab.String("all"),
+			CreatedAfter:   gitlab.Time(after),
+			CreatedBefore:  gitlab.Time(before),
+		}, requestOptions...)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get merge requests for %s: %w", username, err)
+	}
+
+	return userMrs, nil
+}
+
+// FetchProjectMergeRequestsCreatedBetween fetches merge requests a user authored within a project,
+// in any state, that were created in [after, before).
+func (source *GitlabSource) FetchProjectMergeRequestsCreatedBetween(projectId string, username string, after time.Time, before time.Time) ([]*gitlab.MergeRequest, error) {
+	userMrs, err := fetchAllPages(func(listOptions gitlab.ListOptions, requestOptions ...gitlab.RequestOptionFunc) ([]*gitlab.MergeRequest, *gitlab.Response, error) {
+		return source.client.MergeRequests.ListProjectMergeRequests(projectId, &gitlab.ListProjectMergeRequestsOptions{
+			ListOptions:    listOptions,
+			AuthorUsername: gitlab.String(username),
+			State:          gitlab.String("all"),
+			CreatedAfter:   gitlab.Time(after),
+			CreatedBefore:  gitlab.Time(before),
+		}, requestOptions...)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get merge requests for %s: %w", username, err)
+	}
+
+	return userMrs, nil
+}
+
+// FetchReviewActivity fetches the notes and approvals of a merge request and works out when it was reviewed.
+func (source *GitlabSource) FetchReviewActivity(mr *gitlab.MergeRequest) (*ReviewActivity, error) {
+	notes, err := fetchAllPages(func(listOptions gitlab.ListOptions, requestOptions ...gitlab.RequestOptionFunc) ([]*gitlab.Note, *gitlab.Response, error) {
+		return source.client.Notes.ListMergeRequestNotes(mr.ProjectID, mr.IID, &gitlab.ListMergeRequestNotesOptions{
+			ListOptions: listOptions,
+			OrderBy:     gitlab.String("created_at"),
+			Sort:        gitlab.String("asc"),
+		}, requestOptions...)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notes for merge request %d!%d: %w", mr.ProjectID, mr.IID, err)
+	}
+
+	approvals, _, err := source.client.MergeRequestApprovals.GetConfiguration(mr.ProjectID, mr.IID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get approvals for merge request %d!%d: %w", mr.ProjectID, mr.IID, err)
+	}
+
+	return NewReviewActivity(mr, notes, approvals), nil
+}
+
+// NewReviewActivity works out when a merge request was reviewed from its notes and approvals.
+// Comments and approvals by the author don't count as reviews.
+func NewReviewActivity(mr *gitlab.MergeRequest, notes []*gitlab.Note, approvals *gitlab.MergeRequestApprovals) *ReviewActivity {
+	activity := &ReviewActivity{Approvers: []string{}}
+
+	authorId := 0
+	if mr.Author != nil {
+		authorId = mr.Author.ID
+	}
+
+	sortedNotes := append([]*gitlab.Note{}, notes...)
+	sort.SliceStable(sortedNotes, func(i, j int) bool {
+		return compareTimes(sortedNotes[i].CreatedAt, sortedNotes[j].CreatedAt) < 0
+	})
+
+	for _, note := range sortedNotes {
+		if note.CreatedAt == nil || note.Author.ID == authorId {
+			continue
+		}
+
+		isApproval := note.System && note.Body == approvedNote
+		if note.System && !isApproval {
+			continue
+		}
+
+		if activity.FirstReviewAt == nil {
+			activity.FirstReviewAt = note.CreatedAt
+		}
+		if isApproval && activity.ApprovedAt == nil {
+			activity.ApprovedAt = note.CreatedAt
+		}
+	}
+
+	if approvals != nil {
+		for _, approver := range approvals.ApprovedBy {
+			if approver.User != nil {
+				activity.Approvers = append(activity.Approvers, approver.User.Username)
+			}
+		}
+	}
+
+	return activity
+}
+
+// FetchReviewActivities fetches the review activity of every merge request concurrently, keyed by merge request ID.
+func FetchReviewActivities(source MergeRequestSource, mrs []*gitlab.MergeRequest, concurrency int) (map[int]*ReviewActivity, error) {
+	jobs := make([]func() (*ReviewActivity, error), len(mrs))
+	for i, mr := range mrs {
+		mr := mr
+		jobs[i] = func() (*ReviewActivity, error) {
+			return source.FetchReviewActivity(mr)
+		}
+	}
+
+	results, err := runConcurrently(jobs, concurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	activities := make(map[int]*ReviewActivity, len(mrs))
+	for i, mr := range mrs {
+		activities[mr.ID] = results[i]
+	}
+
+	return activities, nil
+}
@@ -0,0 +1,66 @@
+package mrs
+
+import (
+	"fmt"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// MergeRequestDetails holds what GitLab only returns when asking about a single merge request.
+type MergeRequestDetails struct {
+	// PipelineStatus is the status of the head pipeline, or empty if there isn't one.
+	PipelineStatus string
+	// Approvals is how many users approved the merge request.
+	Approvals int
+	// ApprovalsRequired is how many approvals the merge request needs.
+	ApprovalsRequired int
+	// ChangesCount is how many files changed, e.g. `12` or `1000+`.
+	ChangesCount string
+}
+
+// FetchMergeRequestDetails fetches the head pipeline and approvals of a merge request.
+func (source *GitlabSource) FetchMergeRequestDetails(projectId int, iid int) (*MergeRequestDetails, error) {
+	mr, _, err := source.client.MergeRequests.GetMergeRequest(projectId, iid, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get merge request %d!%d: %w", projectId, iid, err)
+	}
+
+	approvals, _, err := source.client.MergeRequestApprovals.GetConfiguration(projectId, iid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get approvals for merge request %d!%d: %w", projectId, iid, err)
+	}
+
+	details := &MergeRequestDetails{
+		Approvals:         len(approvals.ApprovedBy),
+		ApprovalsRequired: approvals.ApprovalsRequired,
+		ChangesCount:      mr.ChangesCount,
+	}
+	if mr.HeadPipeline != nil {
+		details.PipelineStatus = mr.HeadPipeline.Status
+	}
+
+	return details, nil
+}
+
+// FetchDetails fetches the details of every merge request concurrently, keyed by merge request ID.
+func FetchDetails(source MergeRequestSource, mrs []*gitlab.MergeRequest, concurrency int) (map[int]*MergeRequestDetails, error) {
+	jobs := make([]func() (*MergeRequestDetails, error), len(mrs))
+	for i, mr := range mrs {
+		mr := mr
+		jobs[i] = func() (*MergeRequestDetails, error) {
+			return source.FetchMergeRequestDetails(mr.ProjectID, mr.IID)
+		}
+	}
+
+	results, err := runConcurrently(jobs, concurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	details := make(map[int]*MergeRequestDetails, len(mrs))
+	for i, mr := range mrs {
+		details[mr.ID] = results[i]
+	}
+
+	return details, nil
+}
@@ -0,0 +1,128 @@
+package mrs
+
+import (
+	"fmt"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// Kinds of Change.
+const (
+	ChangeAdded     = "added"
+	ChangeRemoved   = "removed"
+	ChangePushed    = "pushed"
+	ChangePipeline  = "pipeline"
+	ChangeMergeable = "mergeable"
+)
+
+// MergeableStatus is the detailed merge status of an MR that's ready to merge.
+const MergeableStatus = "mergeable"
+
+// Snapshot is a queue of merge requests at a point in time, for comparing against a later one.
+type Snapshot struct {
+	order   []string
+	entries map[string]snapshotEntry
+}
+
+type snapshotEntry struct {
+	mr             *gitlab.MergeRequest
+	pipelineStatus string
+}
+
+// Change is a difference between two snapshots.
+type Change struct {
+	Kind         string
+	MergeRequest *gitlab.MergeRequest
+	// Before and After are the old and new pipeline statuses of a ChangePipeline.
+	Before string
+	After  string
+}
+
+// NewSnapshot captures a queue. details may be nil, in which case pipeline changes aren't detected.
+func NewSnapshot(mrs []*gitlab.MergeRequest, details map[int]*MergeRequestDetails) Snapshot {
+	snapshot := Snapshot{entries: make(map[string]snapshotEntry, len(mrs))}
+
+	for _, mr := range mrs {
+		entry := snapshotEntry{mr: mr}
+		if mrDetails, ok := details[mr.ID]; ok {
+			entry.pipelineStatus = mrDetails.PipelineStatus
+		}
+		snapshot.order = append(snapshot.order, mr.WebURL)
+		snapshot.entries[mr.WebURL] = entry
+	}
+
+	return snapshot
+}
+
+// Len returns how many merge requests are in the snapshot.
+func (snapshot Snapshot) Len() int {
+	return len(snapshot.order)
+}
+
+// DiffSnapshots lists what changed from previous to next. MRs that joined or changed come
+// first, in next's order, followed by MRs that left, in previous' order.
+func DiffSnapshots(previous Snapshot, next Snapshot) []Change {
+	var changes []Change
+
+	for _, url := range next.order {
+		nextEntry := next.entries[url]
+		previousEntry, ok := previous.entries[url]
+		if !ok {
+			changes = append(changes, Change{Kind: ChangeAdded, MergeRequest: nextEntry.mr})
+			continue
+		}
+
+		if previousEntry.mr.SHA != "" && nextEntry.mr.SHA != previousEntry.mr.SHA {
+			changes = append(changes, Change{Kind: ChangePushed, MergeRequest: nextEntry.mr})
+		}
+
+		if previousEntry.pipelineStatus != nextEntry.pipelineStatus && nextEntry.pipelineStatus != "" {
+			changes = append(changes, Change{
+				Kind:         ChangePipeline,
+				MergeRequest: nextEntry.mr,
+				Before:       previousEntry.pipelineStatus,
+				After:        nextEntry.pipelineStatus,
+			})
+		}
+
+		if previousEntry.mr.DetailedMergeStatus != MergeableStatus && nextEntry.mr.DetailedMergeStatus == MergeableStatus {
+			changes = append(changes, Change{Kind: ChangeMergeable, MergeRequest: nextEntry.mr})
+		}
+	}
+
+	for _, url := range previous.order {
+		if _, ok := next.entries[url]; !ok {
+			changes = append(changes, Change{Kind: ChangeRemoved, MergeRequest: previous.entries[url].mr})
+		}
+	}
+
+	return changes
+}
+
+// String describes a change in a single line, e.g. `+ @alice: https://...`.
+func (change Change) String() string {
+	mr := change.MergeRequest
+	author := "(no author)"
+	if mr.Author != nil {
+		author = "@" + mr.Author.Username
+	}
+
+	switch change.Kind {
+	case ChangeAdded:
+		return fmt.Sprintf("+ %s: %s (joined the queue)", author, mr.WebURL)
+	case ChangeRemoved:
+		return fmt.Sprintf("- %s: %s (left the queue)", author, mr.WebURL)
+	case ChangePushed:
+		return fmt.Sprintf("~ %s: %s (new commits pushed)", author, mr.WebURL)
+	case ChangePipeline:
+		before := change.Before
+		if before == "" {
+			before = "none"
+		}
+		return fmt.Sprintf("~ %s: %s (pipeline %s → %s)", author, mr.WebURL, before, change.After)
+	case ChangeMergeable:
+		return fmt.Sprintf("~ %s: %s (ready to merge)", author, mr.WebURL)
+	default:
+		return fmt.Sprintf("? %s: %s", author, mr.WebURL)
+	}
+}
@@ -0,0 +1,50 @@
+package mrs
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+func TestDiffSnapshots(t *testing.T) {
+	mr := func(id int, sha string) *gitlab.MergeRequest {
+		return &gitlab.MergeRequest{
+			ID:     id,
+			SHA:    sha,
+			Author: &gitlab.BasicUser{Username: "alice"},
+			WebURL: "https://gitlab.example.com/acme/api/-/merge_requests/" + string(rune('0'+id)),
+		}
+	}
+
+	previous := NewSnapshot(
+		[]*gitlab.MergeRequest{mr(1, "aaa"), mr(2, "bbb"), mr(3, "ccc")},
+		map[int]*MergeRequestDetails{1: {PipelineStatus: "running"}, 2: {PipelineStatus: "running"}},
+	)
+	mergeable := mr(1, "aaa")
+	mergeable.DetailedMergeStatus = "mergeable"
+	next := NewSnapshot(
+		[]*gitlab.MergeRequest{mr(4, "ddd"), mergeable, mr(2, "eee")},
+		map[int]*MergeRequestDetails{1: {PipelineStatus: "failed"}, 2: {PipelineStatus: "running"}},
+	)
+
+	var got []string
+	for _, change := range DiffSnapshots(previous, next) {
+		got = append(got, change.String())
+	}
+
+	want := []string{
+		"+ @alice: https://gitlab.example.com/acme/api/-/merge_requests/4 (joined the queue)",
+		"~ @alice: https://gitlab.example.com/acme/api/-/merge_requests/1 (pipeline running → failed)",
+		"~ @alice: https://gitlab.example.com/acme/api/-/merge_requests/1 (ready to merge)",
+		"~ @alice: https://gitlab.example.com/acme/api/-/merge_requests/2 (new commits pushed)",
+		"- @alice: https://gitlab.example.com/acme/api/-/merge_requests/3 (left the queue)",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffSnapshots() =\n%v\nwant\n%v", got, want)
+	}
+
+	if changes := DiffSnapshots(next, next); len(changes) != 0 {
+		t.Errorf("DiffSnapshots() of identical snapshots = %v, want none", changes)
+	}
+}
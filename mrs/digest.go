@@ -0,0 +1,130 @@
+package mrs
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// DigestOptions configures WriteDigest.
+type DigestOptions struct {
+	// StaleAfter is how long an MR can wait before it's highlighted.
+	StaleAfter time.Duration
+	// Details provide pipeline statuses. Without them, failing pipelines aren't highlighted.
+	Details map[int]*MergeRequestDetails
+	// Since is the start of the window Finished covers. Finished is left out when it's nil.
+	Since *time.Time
+	// Finished are MRs merged or closed since Since.
+	Finished []*gitlab.MergeRequest
+}
+
+// WriteDigest writes a markdown report of the queue, grouped by project, that highlights MRs
+// waiting longer than options.StaleAfter, MRs with failing pipelines and MRs with no reviewers.
+func WriteDigest(w io.Writer, mrs []*gitlab.MergeRequest, options DigestOptions) error {
+	var report strings.Builder
+
+	fmt.Fprintf(&report, "# Review digest for %s\n\n", now().Format("Monday, January 2"))
+
+	var stale, failing, unreviewed int
+	for _, mr := range mrs {
+		if isStale(mr, options.StaleAfter) {
+			stale++
+		}
+		if hasFailingPipeline(mr, options.Details) {
+			failing++
+		}
+		if len(mr.Reviewers) == 0 {
+			unreviewed++
+		}
+	}
+
+	groups, err := GroupMergeRequests(mrs, GroupByProject)
+	if err != nil {
+		return err
+	}
+
+	if len(mrs) == 0 {
+		report.WriteString("The queue is empty.\n")
+	} else {
+		fmt.Fprintf(&report, "%s in %s: %d waiting longer than %s, %d with a failing pipeline, %d with no reviewers.\n",
+			plural(len(mrs), "open MR"), plural(len(groups), "project"), stale, formatDays(options.StaleAfter), failing, unreviewed)
+	}
+
+	for _, group := range groups {
+		fmt.Fprintf(&report, "\n## %s\n\n", group.Name)
+		for _, mr := range group.MergeRequests {
+			fmt.Fprintf(&report, "- %s by %s, opened %s ago", markdownLink(mr), authorMention(mr), RelativeTime(mr.CreatedAt))
+			if highlights := digestHighlights(mr, options); len(highlights) > 0 {
+				fmt.Fprintf(&report, " — **%s**", strings.Join(highlights, "**, **"))
+			}
+			report.WriteString("\n")
+		}
+	}
+
+	if options.Since != nil {
+		fmt.Fprintf(&report, "\n## Merged or closed since %s\n\n", options.Since.Format("Jan 2 15:04"))
+		if len(options.Finished) == 0 {
+			report.WriteString("Nothing yet.\n")
+		}
+		for _, mr := range options.Finished {
+			fmt.Fprintf(&report, "- %s: %s in %s by %s\n", mr.State, markdownLink(mr), ProjectPath(mr), authorMention(mr))
+		}
+	}
+
+	_, err = io.WriteString(w, report.String())
+	return err
+}
+
+func digestHighlights(mr *gitlab.MergeRequest, options DigestOptions) []string {
+	var highlights []string
+	if isStale(mr, options.StaleAfter) {
+		highlights = append(highlights, "waiting "+RelativeTime(mr.CreatedAt))
+	}
+	if hasFailingPipeline(mr, options.Details) {
+		highlights = append(highlights, "pipeline failed")
+	}
+	if len(mr.Reviewers) == 0 {
+		highlights = append(highlights, "no reviewers")
+	}
+	return highlights
+}
+
+func isStale(mr *gitlab.MergeRequest, staleAfter time.Duration) bool {
+	return mr.CreatedAt != nil && now().Sub(*mr.CreatedAt) > staleAfter
+}
+
+func hasFailingPipeline(mr *gitlab.MergeRequest, details map[int]*MergeRequestDetails) bool {
+	mrDetails, ok := details[mr.ID]
+	return ok && mrDetails.PipelineStatus == "failed"
+}
+
+// markdownLink links to an MR, escaping brackets in its title.
+func markdownLink(mr *gitlab.MergeRequest) string {
+	title := strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`).Replace(mr.Title)
+	return fmt.Sprintf("[%s](%s)", title, mr.WebURL)
+}
+
+func authorMention(mr *gitlab.MergeRequest) string {
+	if mr.Author == nil {
+		return "(no author)"
+	}
+	return "@" + mr.Author.Username
+}
+
+func plural(count int, noun string) string {
+	if count == 1 {
+		return fmt.Sprintf("%d %s", count, noun)
+	}
+	return fmt.Sprintf("%d %ss", count, noun)
+}
+
+// formatDays describes a duration in days when it's a whole number of them, e.g. `2d`.
+func formatDays(duration time.Duration) string {
+	if duration >= 24*time.Hour && duration%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%dd", duration/(24*time.Hour))
+	}
+	return duration.String()
+}
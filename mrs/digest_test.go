@@ -0,0 +1,92 @@
+package mrs
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+func TestWriteDigest(t *testing.T) {
+	now = func() time.Time { return time.Date(2023, 9, 4, 10, 0, 0, 0, time.UTC) }
+	t.Cleanup(func() { now = time.Now })
+
+	daysAgo := func(days int) *time.Time {
+		t := now().Add(-time.Duration(days) * 24 * time.Hour)
+		return &t
+	}
+
+	queue := []*gitlab.MergeRequest{
+		{
+			ID:        1,
+			Title:     "Add [beta] widgets",
+			Author:    &gitlab.BasicUser{Username: "alice"},
+			Reviewers: []*gitlab.BasicUser{{Username: "bob"}},
+			CreatedAt: daysAgo(1),
+			WebURL:    "https://gitlab.example.com/acme/web/-/merge_requests/1",
+		},
+		{
+			ID:        2,
+			Title:     "Fix login",
+			Author:    &gitlab.BasicUser{Username: "bob"},
+			CreatedAt: daysAgo(3),
+			WebURL:    "https://gitlab.example.com/acme/api/-/merge_requests/2",
+		},
+		{
+			ID:        3,
+			Title:     "Bump Go",
+			Author:    &gitlab.BasicUser{Username: "carol"},
+			Reviewers: []*gitlab.BasicUser{{Username: "alice"}},
+			CreatedAt: daysAgo(1),
+			WebURL:    "https://gitlab.example.com/acme/web/-/merge_requests/3",
+		},
+	}
+
+	since := time.Date(2023, 9, 3, 10, 0, 0, 0, time.UTC)
+	options := DigestOptions{
+		StaleAfter: 2 * 24 * time.Hour,
+		Details:    map[int]*MergeRequestDetails{3: {PipelineStatus: "failed"}},
+		Since:      &since,
+		Finished: []*gitlab.MergeRequest{
+			{State: "merged", Title: "Cache lookups", Author: &gitlab.BasicUser{Username: "dave"}, WebURL: "https://gitlab.example.com/acme/api/-/merge_requests/4"},
+		},
+	}
+
+	var output bytes.Buffer
+	if err := WriteDigest(&output, queue, options); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `# Review digest for Monday, September 4
+
+3 open MRs in 2 projects: 1 waiting longer than 2d, 1 with a failing pipeline, 1 with no reviewers.
+
+## acme/web
+
+- [Add \[beta\] widgets](https://gitlab.example.com/acme/web/-/merge_requests/1) by @alice, opened 1d ago
+- [Bump Go](https://gitlab.example.com/acme/web/-/merge_requests/3) by @carol, opened 1d ago — **pipeline failed**
+
+## acme/api
+
+- [Fix login](https://gitlab.example.com/acme/api/-/merge_requests/2) by @bob, opened 3d ago — **waiting 3d**, **no reviewers**
+
+## Merged or closed since Sep 3 10:00
+
+- merged: [Cache lookups](https://gitlab.example.com/acme/api/-/merge_requests/4) in acme/api by @dave
+`
+	if output.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", output.String(), want)
+	}
+}
+
+func TestWriteDigestEmpty(t *testing.T) {
+	var output bytes.Buffer
+	if err := WriteDigest(&output, nil, DigestOptions{StaleAfter: 48 * time.Hour}); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Contains(output.Bytes(), []byte("The queue is empty.")) {
+		t.Errorf("got:\n%s\nwant an empty queue", output.String())
+	}
+}
@@ -0,0 +1,68 @@
+package mrs
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// FetchGroupMergeRequestsFinishedSince fetches merge requests a user authored within a group
+// that were merged or closed since the given time.
+func (source *GitlabSource) FetchGroupMergeRequestsFinishedSince(groupId string, username string, since time.Time) ([]*gitlab.MergeRequest, error) {
+	userMrs, err := fetchAllPages(func(listOptions gitlab.ListOptions, requestOptions ...gitlab.RequestOptionFunc) ([]*gitlab.MergeRequest, *gitlab.Response, error) {
+		return source.client.MergeRequests.ListGroupMergeRequests(groupId, &gitlab.ListGroupMergeRequestsOptions{
+			ListOptions:    listOptions,
+			AuthorUsername: gitlab.String(username),
+			State:          gitlab.String("all"),
+			UpdatedAfter:   gitlab.Time(since),
+		}, requestOptions...)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get finished merge requests for %s: %w", username, err)
+	}
+
+	return FinishedSince(userMrs, since), nil
+}
+
+// FetchProjectMergeRequestsFinishedSince fetches merge requests a user authored within a project
+// that were merged or closed since the given time.
+func (source *GitlabSource) FetchProjectMergeRequestsFinishedSince(projectId string, username string, since time.Time) ([]*gitlab.MergeRequest, error) {
+	userMrs, err := fetchAllPages(func(listOptions gitlab.ListOptions, requestOptions ...gitlab.RequestOptionFunc) ([]*gitlab.MergeRequest, *gitlab.Response, error) {
+		return source.client.MergeRequests.ListProjectMergeRequests(projectId, &gitlab.ListProjectMergeRequestsOptions{
+			ListOptions:    listOptions,
+			AuthorUsername: gitlab.String(username),
+			State:          gitlab.String("all"),
+			UpdatedAfter:   gitlab.Time(since),
+		}, requestOptions...)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get finished merge requests for %s: %w", username, err)
+	}
+
+	return FinishedSince(userMrs, since), nil
+}
+
+// FinishedSince keeps the merge requests that were merged or closed since the given time.
+// GitLab can only filter on when an MR was last updated, which includes comments after it's finished.
+func FinishedSince(mrs []*gitlab.MergeRequest, since time.Time) []*gitlab.MergeRequest {
+	finished := []*gitlab.MergeRequest{}
+	for _, mr := range mrs {
+		if finishedAt := FinishedAt(mr); finishedAt != nil && !finishedAt.Before(since) {
+			finished = append(finished, mr)
+		}
+	}
+	return finished
+}
+
+// FinishedAt returns when a merge request was merged or closed, or nil if it's still open.
+func FinishedAt(mr *gitlab.MergeRequest) *time.Time {
+	switch mr.State {
+	case "merged":
+		return mr.MergedAt
+	case "closed":
+		return mr.ClosedAt
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,105 @@
+package mrs
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// Keys supported by GroupMergeRequests.
+const (
+	GroupByProject  = "project"
+	GroupByAuthor   = "author"
+	GroupByReviewer = "reviewer"
+	GroupByLabel    = "label"
+	GroupByStatus   = "status"
+)
+
+// GroupByKeys lists every key merge requests can be grouped by.
+var GroupByKeys = []string{GroupByProject, GroupByAuthor, GroupByReviewer, GroupByLabel, GroupByStatus}
+
+// Group is a section of merge requests sharing a project, author, reviewer, label or status.
+type Group struct {
+	Name          string
+	MergeRequests []*gitlab.MergeRequest
+}
+
+// ValidateGroupBy returns an error if key isn't one of GroupByKeys.
+func ValidateGroupBy(key string) error {
+	for _, groupByKey := range GroupByKeys {
+		if key == groupByKey {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown group by %q; expected one of %s", key, strings.Join(GroupByKeys, ", "))
+}
+
+// GroupMergeRequests splits merge requests into groups, largest first.
+// A merge request with several reviewers or labels appears in each of their groups.
+// Merge requests keep their order within a group.
+func GroupMergeRequests(mrs []*gitlab.MergeRequest, key string) ([]Group, error) {
+	if err := ValidateGroupBy(key); err != nil {
+		return nil, err
+	}
+
+	var groups []*Group
+	groupsByName := map[string]*Group{}
+
+	for _, mr := range mrs {
+		for _, name := range groupNames(mr, key) {
+			group, ok := groupsByName[name]
+			if !ok {
+				group = &Group{Name: name}
+				groupsByName[name] = group
+				groups = append(groups, group)
+			}
+			group.MergeRequests = append(group.MergeRequests, mr)
+		}
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if len(groups[i].MergeRequests) != len(groups[j].MergeRequests) {
+			return len(groups[i].MergeRequests) > len(groups[j].MergeRequests)
+		}
+		return groups[i].Name < groups[j].Name
+	})
+
+	result := make([]Group, len(groups))
+	for i, group := range groups {
+		result[i] = *group
+	}
+
+	return result, nil
+}
+
+func groupNames(mr *gitlab.MergeRequest, key string) []string {
+	switch key {
+	case GroupByProject:
+		return []string{ProjectPath(mr)}
+	case GroupByAuthor:
+		if mr.Author == nil {
+			return []string{"(no author)"}
+		}
+		return []string{"@" + mr.Author.Username}
+	case GroupByReviewer:
+		if len(mr.Reviewers) == 0 {
+			return []string{"(no reviewers)"}
+		}
+		var names []string
+		for _, reviewer := range mr.Reviewers {
+			names = append(names, "@"+reviewer.Username)
+		}
+		return names
+	case GroupByLabel:
+		if len(mr.Labels) == 0 {
+			return []string{"(no labels)"}
+		}
+		return mr.Labels
+	case GroupByStatus:
+		return []string{mr.DetailedMergeStatus}
+	default:
+		return nil
+	}
+}
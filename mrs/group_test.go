@@ -0,0 +1,60 @@
+package mrs
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+func TestGroupMergeRequests(t *testing.T) {
+	alice := &gitlab.BasicUser{Username: "alice"}
+	bob := &gitlab.BasicUser{Username: "bob"}
+	mrs := []*gitlab.MergeRequest{
+		{ID: 1, Author: alice, Reviewers: []*gitlab.BasicUser{bob}, Labels: gitlab.Labels{"api"}, DetailedMergeStatus: "not_approved", References: &gitlab.IssueReferences{Full: "acme/web!1"}, IID: 1},
+		{ID: 2, Author: bob, Reviewers: []*gitlab.BasicUser{alice, bob}, Labels: gitlab.Labels{"api", "bug"}, DetailedMergeStatus: "mergeable", References: &gitlab.IssueReferences{Full: "acme/api!2"}, IID: 2},
+		{ID: 3, Author: alice, DetailedMergeStatus: "not_approved", References: &gitlab.IssueReferences{Full: "acme/api!3"}, IID: 3},
+	}
+
+	tests := []struct {
+		key  string
+		want map[string][]int
+		// order lists the group names, largest first.
+		order []string
+	}{
+		{key: GroupByProject, order: []string{"acme/api", "acme/web"}, want: map[string][]int{"acme/api": {2, 3}, "acme/web": {1}}},
+		{key: GroupByAuthor, order: []string{"@alice", "@bob"}, want: map[string][]int{"@alice": {1, 3}, "@bob": {2}}},
+		{key: GroupByReviewer, order: []string{"@bob", "(no reviewers)", "@alice"}, want: map[string][]int{"@bob": {1, 2}, "@alice": {2}, "(no reviewers)": {3}}},
+		{key: GroupByLabel, order: []string{"api", "(no labels)", "bug"}, want: map[string][]int{"api": {1, 2}, "bug": {2}, "(no labels)": {3}}},
+		{key: GroupByStatus, order: []string{"not_approved", "mergeable"}, want: map[string][]int{"not_approved": {1, 3}, "mergeable": {2}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			groups, err := GroupMergeRequests(mrs, tt.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var order []string
+			got := map[string][]int{}
+			for _, group := range groups {
+				order = append(order, group.Name)
+				for _, mr := range group.MergeRequests {
+					got[group.Name] = append(got[group.Name], mr.ID)
+				}
+			}
+
+			if !reflect.DeepEqual(order, tt.order) {
+				t.Errorf("group order = %v, want %v", order, tt.order)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("groups = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := GroupMergeRequests(mrs, "milestone"); err == nil {
+		t.Error("expected an error for an unknown key")
+	}
+}
@@ -4,10 +4,10 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"os"
 	"os/exec"
 	"runtime"
 
-	"github.com/mjburtenshaw/macglab/glab"
 	"github.com/xanzy/go-gitlab"
 )
 
@@ -48,11 +48,11 @@ func getWIPQueryParamPointer(shouldIncludeDrafts *bool) *string {
 }
 
 // FetchGroupMergeRequests fetches merge requests for a group from GitLab.
-func FetchGroupMergeRequests(glabClient *glab.TGitlabClient, groupId string, usernames []string, shouldIncludeDrafts *bool) ([]*gitlab.MergeRequest, error) {
+func (source *GitlabSource) FetchGroupMergeRequests(groupId string, usernames []string, shouldIncludeDrafts *bool) ([]*gitlab.MergeRequest, error) {
 	var groupMrs []*gitlab.MergeRequest
 
 	for _, username := range usernames {
-		userMrs, err := fetchUserMergeRequests(glabClient, groupId, username, shouldIncludeDrafts)
+		userMrs, err := source.fetchUserMergeRequests(groupId, username, shouldIncludeDrafts)
 		if err != nil {
 			return nil, err
 		}
@@ -63,11 +63,14 @@ func FetchGroupMergeRequests(glabClient *glab.TGitlabClient, groupId string, use
 }
 
 // fetchUserMergeRequests fetches merge requests for a specific user within a group from GitLab.
-func fetchUserMergeRequests(glabClient *glab.TGitlabClient, groupId string, username string, shouldIncludeDrafts *bool) ([]*gitlab.MergeRequest, error) {
-	userMrs, _, err := glabClient.MergeRequests.ListGroupMergeRequests(groupId, &gitlab.ListGroupMergeRequestsOptions{
-		AuthorUsername: gitlab.String(username),
-		State:          gitlab.String("opened"),
-		WIP:            getWIPQueryParamPointer(shouldIncludeDrafts),
+func (source *GitlabSource) fetchUserMergeRequests(groupId string, username string, shouldIncludeDrafts *bool) ([]*gitlab.MergeRequest, error) {
+	userMrs, err := fetchAllPages(func(listOptions gitlab.ListOptions, requestOptions ...gitlab.RequestOptionFunc) ([]*gitlab.MergeRequest, *gitlab.Response, error) {
+		return source.client.MergeRequests.ListGroupMergeRequests(groupId, &gitlab.ListGroupMergeRequestsOptions{
+			ListOptions:    listOptions,
+			AuthorUsername: gitlab.String(username),
+			State:          gitlab.String("opened"),
+			WIP:            getWIPQueryParamPointer(shouldIncludeDrafts),
+		}, requestOptions...)
 	})
 	if err != nil {
 		log.Printf("Failed to get merge requests for %s: %v\n", username, err)
@@ -77,12 +80,15 @@ func fetchUserMergeRequests(glabClient *glab.TGitlabClient, groupId string, user
 	return userMrs, nil
 }
 
-// FetchUserMergeRequests fetches merge requests for a specific reviewer within a group from GitLab.
-func FetchReviewerMergeRequests(glabClient *glab.TGitlabClient, groupId string, userId int, shouldIncludeDrafts *bool) ([]*gitlab.MergeRequest, error) {
-	userMrs, _, err := glabClient.MergeRequests.ListGroupMergeRequests(groupId, &gitlab.ListGroupMergeRequestsOptions{
-		ReviewerID: 		gitlab.ReviewerID(userId),
-		State:          gitlab.String("opened"),
-		WIP:            getWIPQueryParamPointer(shouldIncludeDrafts),
+// FetchReviewerMergeRequests fetches merge requests for a specific reviewer within a group from GitLab.
+func (source *GitlabSource) FetchReviewerMergeRequests(groupId string, userId int, shouldIncludeDrafts *bool) ([]*gitlab.MergeRequest, error) {
+	userMrs, err := fetchAllPages(func(listOptions gitlab.ListOptions, requestOptions ...gitlab.RequestOptionFunc) ([]*gitlab.MergeRequest, *gitlab.Response, error) {
+		return source.client.MergeRequests.ListGroupMergeRequests(groupId, &gitlab.ListGroupMergeRequestsOptions{
+			ListOptions: listOptions,
+			ReviewerID:  gitlab.ReviewerID(userId),
+			State:       gitlab.String("opened"),
+			WIP:         getWIPQueryParamPointer(shouldIncludeDrafts),
+		}, requestOptions...)
 	})
 	if err != nil {
 		log.Printf("Failed to get merge requests for %v: %v\n", userId, err)
@@ -93,14 +99,17 @@ func FetchReviewerMergeRequests(glabClient *glab.TGitlabClient, groupId string,
 }
 
 // FetchProjectMergeRequests fetches merge requests for a project from GitLab.
-func FetchProjectMergeRequests(glabClient *glab.TGitlabClient, projectId string, usernames []string, shouldIncludeDrafts *bool) ([]*gitlab.MergeRequest, error) {
+func (source *GitlabSource) FetchProjectMergeRequests(projectId string, usernames []string, shouldIncludeDrafts *bool) ([]*gitlab.MergeRequest, error) {
 	var projectMrs []*gitlab.MergeRequest
 
 	for _, username := range usernames {
-		userMrs, _, err := glabClient.MergeRequests.ListProjectMergeRequests(projectId, &gitlab.ListProjectMergeRequestsOptions{
-			AuthorUsername: gitlab.String(username),
-			State:          gitlab.String("opened"),
-			WIP:            getWIPQueryParamPointer(shouldIncludeDrafts),
+		userMrs, err := fetchAllPages(func(listOptions gitlab.ListOptions, requestOptions ...gitlab.RequestOptionFunc) ([]*gitlab.MergeRequest, *gitlab.Response, error) {
+			return source.client.MergeRequests.ListProjectMergeRequests(projectId, &gitlab.ListProjectMergeRequestsOptions{
+				ListOptions:    listOptions,
+				AuthorUsername: gitlab.String(username),
+				State:          gitlab.String("opened"),
+				WIP:            getWIPQueryParamPointer(shouldIncludeDrafts),
+			}, requestOptions...)
 		})
 		if err != nil {
 			return nil, fmt.Errorf("failed to get merge request for %s: %w", username, err)
@@ -114,9 +123,7 @@ func FetchProjectMergeRequests(glabClient *glab.TGitlabClient, projectId string,
 
 // PrintMergeRequests prints the details of the merge requests to the console.
 func PrintMergeRequests(mrs []*gitlab.MergeRequest) {
-	for _, mr := range mrs {
-		fmt.Printf("@%s: %s\n", mr.Author.Username, mr.WebURL)
-	}
+	WriteMergeRequests(os.Stdout, OutputText, mrs)
 }
 
 // OpenMergeRequests opens the URLs of the merge requests in the user's default browser.
@@ -129,11 +136,15 @@ func OpenMergeRequests(mrs []*gitlab.MergeRequest) error {
 	return nil
 }
 
-func GetMergeRequestsApprovedByMe(glabClient *glab.TGitlabClient, groupId string, myId int, shouldIncludeDrafts *bool) ([]*gitlab.MergeRequest, error) {
-	mrsApprovedByMe, _, err := glabClient.MergeRequests.ListGroupMergeRequests(groupId, &gitlab.ListGroupMergeRequestsOptions{
-		ApprovedByIDs: gitlab.ApproverIDs([]int{myId}),
-		State:         gitlab.String("opened"),
-		WIP:           getWIPQueryParamPointer(shouldIncludeDrafts),
+// GetMergeRequestsApprovedByMe fetches open merge requests within a group approved by the given user ID.
+func (source *GitlabSource) GetMergeRequestsApprovedByMe(groupId string, myId int, shouldIncludeDrafts *bool) ([]*gitlab.MergeRequest, error) {
+	mrsApprovedByMe, err := fetchAllPages(func(listOptions gitlab.ListOptions, requestOptions ...gitlab.RequestOptionFunc) ([]*gitlab.MergeRequest, *gitlab.Response, error) {
+		return source.client.MergeRequests.ListGroupMergeRequests(groupId, &gitlab.ListGroupMergeRequestsOptions{
+			ListOptions:   listOptions,
+			ApprovedByIDs: gitlab.ApproverIDs([]int{myId}),
+			State:         gitlab.String("opened"),
+			WIP:           getWIPQueryParamPointer(shouldIncludeDrafts),
+		}, requestOptions...)
 	})
 	if err != nil {
 		log.Printf("Failed to get merge requests approved by me: %v\n", err)
@@ -0,0 +1,164 @@
+package mrs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/xanzy/go-gitlab"
+	"gopkg.in/yaml.v2"
+)
+
+// SchemaVersion is bumped whenever a field in MergeRequestRecord is renamed, removed or changes type.
+// Adding a field doesn't bump it.
+const SchemaVersion = 1
+
+// Output formats supported by WriteMergeRequests.
+const (
+	OutputText   = "text"
+	OutputJson   = "json"
+	OutputNdjson = "ndjson"
+	OutputYaml   = "yaml"
+	OutputTable  = "table"
+)
+
+// OutputFormats lists every supported output format.
+var OutputFormats = []string{OutputText, OutputJson, OutputNdjson, OutputYaml, OutputTable}
+
+// UserRecord is a GitLab user in machine-readable output.
+type UserRecord struct {
+	ID       int    `json:"id" yaml:"id"`
+	Username string `json:"username" yaml:"username"`
+	Name     string `json:"name" yaml:"name"`
+}
+
+// MergeRequestRecord is the stable, machine-readable shape of a merge request.
+type MergeRequestRecord struct {
+	SchemaVersion       int          `json:"schema_version" yaml:"schema_version"`
+	ProjectPath         string       `json:"project_path" yaml:"project_path"`
+	IID                 int          `json:"iid" yaml:"iid"`
+	Title               string       `json:"title" yaml:"title"`
+	Author              UserRecord   `json:"author" yaml:"author"`
+	Reviewers           []UserRecord `json:"reviewers" yaml:"reviewers"`
+	Labels              []string     `json:"labels" yaml:"labels"`
+	Draft               bool         `json:"draft" yaml:"draft"`
+	DetailedMergeStatus string       `json:"detailed_merge_status" yaml:"detailed_merge_status"`
+	CreatedAt           *time.Time   `json:"created_at" yaml:"created_at"`
+	UpdatedAt           *time.Time   `json:"updated_at" yaml:"updated_at"`
+	WebURL              string       `json:"web_url" yaml:"web_url"`
+}
+
+// MergeRequestsDocument wraps the records for the json and yaml formats.
+type MergeRequestsDocument struct {
+	SchemaVersion int                  `json:"schema_version" yaml:"schema_version"`
+	MergeRequests []MergeRequestRecord `json:"merge_requests" yaml:"merge_requests"`
+}
+
+// ValidateOutputFormat returns an error if format isn't one of OutputFormats.
+func ValidateOutputFormat(format string) error {
+	for _, outputFormat := range OutputFormats {
+		if format == outputFormat {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown output format %q; expected one of %s", format, strings.Join(OutputFormats, ", "))
+}
+
+// ProjectPath returns the full path of the project a merge request belongs to, e.g. `group/project`.
+func ProjectPath(mr *gitlab.MergeRequest) string {
+	if mr.References != nil && mr.References.Full != "" {
+		return strings.TrimSuffix(mr.References.Full, fmt.Sprintf("!%d", mr.IID))
+	}
+
+	// Older GitLab versions don't send references, so fall back to the web URL:
+	// https://gitlab.com/group/project/-/merge_requests/1
+	if path, _, ok := strings.Cut(mr.WebURL, "/-/merge_requests/"); ok {
+		if _, afterScheme, ok := strings.Cut(path, "://"); ok {
+			path = afterScheme
+		}
+		if _, afterHost, ok := strings.Cut(path, "/"); ok {
+			return afterHost
+		}
+	}
+
+	return fmt.Sprintf("%d", mr.ProjectID)
+}
+
+// NewMergeRequestRecord converts a GitLab merge request into its machine-readable shape.
+func NewMergeRequestRecord(mr *gitlab.MergeRequest) MergeRequestRecord {
+	record := MergeRequestRecord{
+		SchemaVersion:       SchemaVersion,
+		ProjectPath:         ProjectPath(mr),
+		IID:                 mr.IID,
+		Title:               mr.Title,
+		Reviewers:           []UserRecord{},
+		Labels:              []string{},
+		Draft:               mr.Draft,
+		DetailedMergeStatus: mr.DetailedMergeStatus,
+		CreatedAt:           mr.CreatedAt,
+		UpdatedAt:           mr.UpdatedAt,
+		WebURL:              mr.WebURL,
+	}
+
+	if mr.Author != nil {
+		record.Author = newUserRecord(mr.Author)
+	}
+
+	for _, reviewer := range mr.Reviewers {
+		record.Reviewers = append(record.Reviewers, newUserRecord(reviewer))
+	}
+
+	record.Labels = append(record.Labels, mr.Labels...)
+
+	return record
+}
+
+func newUserRecord(user *gitlab.BasicUser) UserRecord {
+	return UserRecord{
+		ID:       user.ID,
+		Username: user.Username,
+		Name:     user.Name,
+	}
+}
+
+// WriteMergeRequests writes merge requests to w in the given output format.
+func WriteMergeRequests(w io.Writer, format string, mrs []*gitlab.MergeRequest) error {
+	records := make([]MergeRequestRecord, 0, len(mrs))
+	for _, mr := range mrs {
+		records = append(records, NewMergeRequestRecord(mr))
+	}
+
+	switch format {
+	case OutputText, "":
+		tmpl, err := ParseFormat(DefaultFormat, nil)
+		if err != nil {
+			return err
+		}
+		return WriteTemplate(w, tmpl, mrs)
+	case OutputJson:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(MergeRequestsDocument{SchemaVersion: SchemaVersion, MergeRequests: records})
+	case OutputNdjson:
+		encoder := json.NewEncoder(w)
+		for _, record := range records {
+			if err := encoder.Encode(record); err != nil {
+				return err
+			}
+		}
+		return nil
+	case OutputYaml:
+		output, err := yaml.Marshal(MergeRequestsDocument{SchemaVersion: SchemaVersion, MergeRequests: records})
+		if err != nil {
+			return fmt.Errorf("couldn't marshal merge requests: %w", err)
+		}
+		_, err = w.Write(output)
+		return err
+	case OutputTable:
+		return WriteTable(w, mrs, nil, TableOptions{Columns: DefaultColumns})
+	default:
+		return ValidateOutputFormat(format)
+	}
+}
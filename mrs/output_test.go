@@ -0,0 +1,117 @@
+package mrs
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+func TestProjectPath(t *testing.T) {
+	tests := []struct {
+		name string
+		mr   *gitlab.MergeRequest
+		want string
+	}{
+		{
+			name: "from references",
+			mr:   &gitlab.MergeRequest{IID: 12, References: &gitlab.IssueReferences{Full: "platform/api!12"}},
+			want: "platform/api",
+		},
+		{
+			name: "from web URL",
+			mr:   &gitlab.MergeRequest{IID: 12, WebURL: "https://gitlab.example.com/platform/tools/cli/-/merge_requests/12"},
+			want: "platform/tools/cli",
+		},
+		{
+			name: "from project ID",
+			mr:   &gitlab.MergeRequest{IID: 12, ProjectID: 99},
+			want: "99",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ProjectPath(tt.mr); got != tt.want {
+				t.Errorf("ProjectPath() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteMergeRequests(t *testing.T) {
+	createdAt := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+	mrs := []*gitlab.MergeRequest{
+		{
+			IID:       1,
+			Title:     "Add widget API",
+			Author:    &gitlab.BasicUser{ID: 2, Username: "alice"},
+			Reviewers: []*gitlab.BasicUser{{ID: 7, Username: "mia"}},
+			Labels:    gitlab.Labels{"backend"},
+			CreatedAt: &createdAt,
+			WebURL:    "https://gitlab.example.com/acme/api/-/merge_requests/1",
+		},
+		{
+			IID:    2,
+			Title:  "Fix flaky pipeline",
+			Author: &gitlab.BasicUser{ID: 7, Username: "mia"},
+			WebURL: "https://gitlab.example.com/acme/web/-/merge_requests/2",
+		},
+	}
+
+	t.Run("text", func(t *testing.T) {
+		var output bytes.Buffer
+		if err := WriteMergeRequests(&output, OutputText, mrs); err != nil {
+			t.Fatal(err)
+		}
+		want := "@alice: https://gitlab.example.com/acme/api/-/merge_requests/1\n@mia: https://gitlab.example.com/acme/web/-/merge_requests/2\n"
+		if output.String() != want {
+			t.Errorf("got %q, want %q", output.String(), want)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var output bytes.Buffer
+		if err := WriteMergeRequests(&output, OutputJson, mrs); err != nil {
+			t.Fatal(err)
+		}
+		var document MergeRequestsDocument
+		if err := json.Unmarshal(output.Bytes(), &document); err != nil {
+			t.Fatal(err)
+		}
+		if document.SchemaVersion != SchemaVersion || len(document.MergeRequests) != 2 {
+			t.Fatalf("got %+v", document)
+		}
+		first := document.MergeRequests[0]
+		if first.ProjectPath != "acme/api" || first.Author.Username != "alice" || first.Reviewers[0].Username != "mia" || first.Labels[0] != "backend" || !first.CreatedAt.Equal(createdAt) {
+			t.Errorf("got %+v", first)
+		}
+	})
+
+	t.Run("ndjson", func(t *testing.T) {
+		var output bytes.Buffer
+		if err := WriteMergeRequests(&output, OutputNdjson, mrs); err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+		if len(lines) != 2 {
+			t.Fatalf("got %d lines, want 2", len(lines))
+		}
+		var record MergeRequestRecord
+		if err := json.Unmarshal([]byte(lines[1]), &record); err != nil {
+			t.Fatal(err)
+		}
+		if record.SchemaVersion != SchemaVersion || record.IID != 2 || record.Reviewers == nil {
+			t.Errorf("got %+v", record)
+		}
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		if err := WriteMergeRequests(&bytes.Buffer{}, "xml", mrs); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
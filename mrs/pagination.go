@@ -0,0 +1,117 @@
+package mrs
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/hashicorp/go-retryablehttp"
+	"github.com/xanzy/go-gitlab"
+)
+
+const (
+	// DefaultPerPage is GitLab's maximum page size for list endpoints.
+	DefaultPerPage = 100
+	// DefaultMaxPages stops a single query after 50 pages (5,000 MRs at the default page size).
+	DefaultMaxPages = 50
+)
+
+// ErrPageLimit is returned when a query has more pages than Pagination.MaxPages allows.
+var ErrPageLimit = errors.New("page limit reached")
+
+// PaginationOptions controls how queries walk GitLab's paginated responses.
+type PaginationOptions struct {
+	// PerPage is the number of results requested per page.
+	PerPage int
+	// MaxPages is a hard cap on the number of pages fetched for a single query.
+	MaxPages int
+}
+
+// Pagination applies to every list query in this package.
+var Pagination = PaginationOptions{
+	PerPage:  DefaultPerPage,
+	MaxPages: DefaultMaxPages,
+}
+
+// SetPagination overrides the pagination options. Zero values keep the defaults.
+func SetPagination(perPage int, maxPages int) {
+	Pagination = PaginationOptions{
+		PerPage:  DefaultPerPage,
+		MaxPages: DefaultMaxPages,
+	}
+	if perPage > 0 {
+		Pagination.PerPage = perPage
+	}
+	if maxPages > 0 {
+		Pagination.MaxPages = maxPages
+	}
+}
+
+// pageFetcher fetches a single page of results, e.g. merge requests or notes.
+type pageFetcher[T any] func(listOptions gitlab.ListOptions, requestOptions ...gitlab.RequestOptionFunc) ([]T, *gitlab.Response, error)
+
+// fetchAllPages calls fetch until GitLab reports there are no more pages.
+// It follows `X-Next-Page` for offset pagination and the `Link` header for keyset pagination.
+func fetchAllPages[T any](fetch pageFetcher[T]) ([]T, error) {
+	var allResults []T
+
+	listOptions := gitlab.ListOptions{Page: 1, PerPage: Pagination.PerPage}
+	var requestOptions []gitlab.RequestOptionFunc
+
+	for page := 1; ; page++ {
+		if page > Pagination.MaxPages {
+			return nil, fmt.Errorf("%w: stopped after %d pages of %d results; raise max_pages in your config", ErrPageLimit, Pagination.MaxPages, Pagination.PerPage)
+		}
+
+		pageResults, response, err := fetch(listOptions, requestOptions...)
+		if err != nil {
+			return nil, err
+		}
+		allResults = append(allResults, pageResults...)
+
+		if response == nil {
+			return allResults, nil
+		}
+
+		if response.NextPage != 0 {
+			listOptions.Page = response.NextPage
+			continue
+		}
+
+		nextLink := parseNextLink(response.Header.Get("Link"))
+		if nextLink == "" {
+			return allResults, nil
+		}
+		requestOptions = []gitlab.RequestOptionFunc{withNextLink(nextLink)}
+	}
+}
+
+// parseNextLink returns the URL of the `rel="next"` entry of a `Link` header, if any.
+func parseNextLink(linkHeader string) string {
+	for _, link := range strings.Split(linkHeader, ",") {
+		parts := strings.Split(link, ";")
+		if len(parts) < 2 {
+			continue
+		}
+		for _, param := range parts[1:] {
+			if strings.TrimSpace(param) == `rel="next"` {
+				return strings.Trim(strings.TrimSpace(parts[0]), "<>")
+			}
+		}
+	}
+	return ""
+}
+
+// withNextLink points a request at the next keyset page GitLab gave us.
+func withNextLink(nextLink string) gitlab.RequestOptionFunc {
+	return func(req *retryablehttp.Request) error {
+		nextUrl, err := url.Parse(nextLink)
+		if err != nil {
+			return fmt.Errorf("couldn't parse next page link %s: %w", nextLink, err)
+		}
+		req.URL = nextUrl
+		req.Host = nextUrl.Host
+		return nil
+	}
+}
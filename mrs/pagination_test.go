@@ -0,0 +1,99 @@
+package mrs
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mjburtenshaw/macglab/fakegitlab"
+	"github.com/xanzy/go-gitlab"
+)
+
+func newFakeGitlabSource(t *testing.T, count int) *GitlabSource {
+	t.Helper()
+
+	fixtures := make([]fakegitlab.Fixture, count)
+	for i := range fixtures {
+		fixtures[i] = fakegitlab.Fixture{
+			MergeRequest: gitlab.MergeRequest{
+				ID:     i + 1,
+				State:  "opened",
+				Author: &gitlab.BasicUser{Username: "alice"},
+			},
+			GroupId: "42",
+		}
+	}
+
+	server := fakegitlab.NewServer(fixtures)
+	t.Cleanup(server.Close)
+
+	glabClient, err := server.Client()
+	if err != nil {
+		t.Fatalf("couldn't create client: %v", err)
+	}
+
+	return NewGitlabSource(glabClient)
+}
+
+func TestFetchAllPages(t *testing.T) {
+	tests := []struct {
+		name     string
+		count    int
+		perPage  int
+		maxPages int
+		wantErr  error
+	}{
+		{name: "single page", count: 3, perPage: 10},
+		{name: "several pages", count: 7, perPage: 2},
+		{name: "exactly the page limit", count: 4, perPage: 2, maxPages: 2},
+		{name: "over the page limit", count: 5, perPage: 2, maxPages: 2, wantErr: ErrPageLimit},
+		{name: "no results", count: 0, perPage: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetPagination(tt.perPage, tt.maxPages)
+			t.Cleanup(func() { SetPagination(0, 0) })
+
+			got, err := newFakeGitlabSource(t, tt.count).FetchGroupMergeRequests("42", []string{"alice"}, nil)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("FetchGroupMergeRequests() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && len(got) != tt.count {
+				t.Errorf("FetchGroupMergeRequests() returned %d MRs, want %d", len(got), tt.count)
+			}
+		})
+	}
+}
+
+func TestParseNextLink(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   string
+	}{
+		{name: "empty", header: "", want: ""},
+		{
+			name:   "next only",
+			header: `<https://gitlab.example.com/api/v4/projects?cursor=abc>; rel="next"`,
+			want:   "https://gitlab.example.com/api/v4/projects?cursor=abc",
+		},
+		{
+			name:   "next among others",
+			header: `<https://gitlab.example.com/api/v4/projects?page=1>; rel="first", <https://gitlab.example.com/api/v4/projects?page=3>; rel="next"`,
+			want:   "https://gitlab.example.com/api/v4/projects?page=3",
+		},
+		{
+			name:   "no next",
+			header: `<https://gitlab.example.com/api/v4/projects?page=1>; rel="first"`,
+			want:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseNextLink(tt.header); got != tt.want {
+				t.Errorf("parseNextLink() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package mrs
+
+import (
+	"sync"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// DefaultConcurrency is how many queries run at once when no concurrency is configured.
+const DefaultConcurrency = 4
+
+// Fetch is a single merge request query.
+type Fetch func() ([]*gitlab.MergeRequest, error)
+
+// FetchConcurrently runs fetches over a pool of at most concurrency workers.
+// Results are returned in the same order as fetches, regardless of which finished first,
+// so callers get identical output between runs. If any fetch fails, the error of the
+// earliest failing fetch is returned and fetches that haven't started yet are skipped.
+func FetchConcurrently(fetches []Fetch, concurrency int) ([][]*gitlab.MergeRequest, error) {
+	jobs := make([]func() ([]*gitlab.MergeRequest, error), len(fetches))
+	for i, fetch := range fetches {
+		jobs[i] = fetch
+	}
+	return runConcurrently(jobs, concurrency)
+}
+
+// runConcurrently is FetchConcurrently for any kind of result.
+func runConcurrently[T any](jobs []func() (T, error), concurrency int) ([]T, error) {
+	if concurrency < 1 {
+		concurrency = DefaultConcurrency
+	}
+
+	results := make([]T, len(jobs))
+	errs := make([]error, len(jobs))
+
+	var mu sync.Mutex
+	failed := false
+
+	queue := make(chan int)
+	var wg sync.WaitGroup
+	for worker := 0; worker < concurrency && worker < len(jobs); worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				mu.Lock()
+				shouldSkip := failed
+				mu.Unlock()
+				if shouldSkip {
+					continue
+				}
+
+				results[i], errs[i] = jobs[i]()
+				if errs[i] != nil {
+					mu.Lock()
+					failed = true
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	for i := range jobs {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return results, nil
+}
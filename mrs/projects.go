@@ -0,0 +1,34 @@
+package mrs
+
+import (
+	"fmt"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// FetchProject implements MergeRequestSource.
+func (source *GitlabSource) FetchProject(project string) (*gitlab.Project, error) {
+	gitlabProject, _, err := source.client.Projects.GetProject(project, nil)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get project %s: %w", project, err)
+	}
+
+	return gitlabProject, nil
+}
+
+// FetchNamespaceProjects implements MergeRequestSource.
+func (source *GitlabSource) FetchNamespaceProjects(namespace string, includeSubgroups bool) ([]*gitlab.Project, error) {
+	projects, err := fetchAllPages(func(listOptions gitlab.ListOptions, requestOptions ...gitlab.RequestOptionFunc) ([]*gitlab.Project, *gitlab.Response, error) {
+		return source.client.Groups.ListGroupProjects(namespace, &gitlab.ListGroupProjectsOptions{
+			ListOptions:      listOptions,
+			Archived:         gitlab.Bool(false),
+			IncludeSubGroups: gitlab.Bool(includeSubgroups),
+			WithShared:       gitlab.Bool(false),
+		}, requestOptions...)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't list the projects in %s: %w", namespace, err)
+	}
+
+	return projects, nil
+}
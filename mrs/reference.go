@@ -0,0 +1,79 @@
+package mrs
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// Reference identifies a merge request by its project and IID, like GitLab's `group/project!123`.
+type Reference struct {
+	// Project is the project's path, e.g. `group/project`, or its ID.
+	Project string
+	IID     int
+}
+
+// NewReference returns the reference of a merge request.
+func NewReference(mr *gitlab.MergeRequest) Reference {
+	return Reference{Project: ProjectPath(mr), IID: mr.IID}
+}
+
+// ParseReference parses `<project>!<iid>`, where project is a path or an ID, or a merge request's URL.
+func ParseReference(value string) (Reference, error) {
+	if strings.Contains(value, "://") {
+		return parseReferenceUrl(value)
+	}
+
+	separator := strings.LastIndex(value, "!")
+	if separator <= 0 {
+		return Reference{}, fmt.Errorf("couldn't parse %q; expected <project>!<iid> or a merge request URL", value)
+	}
+
+	iid, err := strconv.Atoi(value[separator+1:])
+	if err != nil || iid <= 0 {
+		return Reference{}, fmt.Errorf("couldn't parse %q; %q isn't a merge request IID", value, value[separator+1:])
+	}
+
+	return Reference{Project: value[:separator], IID: iid}, nil
+}
+
+// parseReferenceUrl parses URLs like `https://gitlab.com/group/project/-/merge_requests/123/diffs`.
+func parseReferenceUrl(value string) (Reference, error) {
+	parsedUrl, err := url.Parse(value)
+	if err != nil {
+		return Reference{}, fmt.Errorf("couldn't parse %q: %w", value, err)
+	}
+
+	path := strings.Replace(parsedUrl.Path, "/-/merge_requests/", "/merge_requests/", 1)
+	project, rest, ok := strings.Cut(path, "/merge_requests/")
+	project = strings.Trim(project, "/")
+	if !ok || project == "" {
+		return Reference{}, fmt.Errorf("couldn't parse %q; it isn't a merge request URL", value)
+	}
+
+	iidSegment, _, _ := strings.Cut(rest, "/")
+	iid, err := strconv.Atoi(iidSegment)
+	if err != nil || iid <= 0 {
+		return Reference{}, fmt.Errorf("couldn't parse %q; it isn't a merge request URL", value)
+	}
+
+	return Reference{Project: project, IID: iid}, nil
+}
+
+// String formats the reference like GitLab does, e.g. `group/project!123`.
+func (ref Reference) String() string {
+	return fmt.Sprintf("%s!%d", ref.Project, ref.IID)
+}
+
+// FetchMergeRequest implements MergeRequestSource.
+func (source *GitlabSource) FetchMergeRequest(ref Reference) (*gitlab.MergeRequest, error) {
+	mr, _, err := source.client.MergeRequests.GetMergeRequest(ref.Project, ref.IID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get %s: %w", ref, err)
+	}
+
+	return mr, nil
+}
@@ -0,0 +1,46 @@
+package mrs
+
+import (
+	"testing"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    Reference
+		wantErr bool
+	}{
+		{value: "acme/web!42", want: Reference{Project: "acme/web", IID: 42}},
+		{value: "123!7", want: Reference{Project: "123", IID: 7}},
+		{value: "acme/sub/web!1", want: Reference{Project: "acme/sub/web", IID: 1}},
+		{value: "https://gitlab.example.com/acme/web/-/merge_requests/42", want: Reference{Project: "acme/web", IID: 42}},
+		{value: "https://gitlab.example.com/acme/web/-/merge_requests/42/diffs?commit_id=abc", want: Reference{Project: "acme/web", IID: 42}},
+		{value: "https://gitlab.example.com/acme/web/merge_requests/42", want: Reference{Project: "acme/web", IID: 42}},
+		{value: "acme/web", wantErr: true},
+		{value: "!42", wantErr: true},
+		{value: "acme/web!abc", wantErr: true},
+		{value: "acme/web!0", wantErr: true},
+		{value: "https://gitlab.example.com/acme/web/-/issues/42", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseReference(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseReference() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseReference() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewReference(t *testing.T) {
+	mr := &gitlab.MergeRequest{IID: 3, WebURL: "https://gitlab.example.com/acme/web/-/merge_requests/3"}
+	if got := NewReference(mr).String(); got != "acme/web!3" {
+		t.Errorf("NewReference() = %s, want acme/web!3", got)
+	}
+}
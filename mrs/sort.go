@@ -0,0 +1,157 @@
+package mrs
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// Keys supported by SortMergeRequests.
+const (
+	SortByCreated  = "created"
+	SortByUpdated  = "updated"
+	SortByAge      = "age"
+	SortBySize     = "size"
+	SortByAuthor   = "author"
+	SortByProject  = "project"
+	SortByPriority = "priority"
+)
+
+// SortKeys lists every key merge requests can be sorted by.
+var SortKeys = []string{SortByCreated, SortByUpdated, SortByAge, SortBySize, SortByAuthor, SortByProject, SortByPriority}
+
+// SortOptions holds what some sort keys need beyond the merge request itself.
+type SortOptions struct {
+	// Me is the user ID whose review requests come first when sorting by priority.
+	Me int
+	// Details are needed when sorting by size.
+	Details map[int]*MergeRequestDetails
+}
+
+// ValidateSortKey returns an error if key isn't one of SortKeys.
+func ValidateSortKey(key string) error {
+	for _, sortKey := range SortKeys {
+		if key == sortKey {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown sort %q; expected one of %s", key, strings.Join(SortKeys, ", "))
+}
+
+// SortNeedsDetails reports whether sorting by key requires MergeRequestDetails.
+func SortNeedsDetails(key string) bool {
+	return key == SortBySize
+}
+
+// SortMergeRequests sorts merge requests in place:
+//   - created: newest first.
+//   - updated: most recently updated first.
+//   - age: oldest first.
+//   - size: fewest changed files first.
+//   - author: by username.
+//   - project: by project path, then by IID.
+//   - priority: MRs where Me is a reviewer first, then oldest first.
+//
+// Ties are broken by age so the result is the same between runs. reverse flips the order.
+func SortMergeRequests(mrs []*gitlab.MergeRequest, key string, reverse bool, options SortOptions) error {
+	if err := ValidateSortKey(key); err != nil {
+		return err
+	}
+
+	var compare func(a, b *gitlab.MergeRequest) int
+	switch key {
+	case SortByCreated:
+		compare = func(a, b *gitlab.MergeRequest) int { return -compareTimes(a.CreatedAt, b.CreatedAt) }
+	case SortByUpdated:
+		compare = func(a, b *gitlab.MergeRequest) int { return -compareTimes(a.UpdatedAt, b.UpdatedAt) }
+	case SortByAge:
+		compare = func(a, b *gitlab.MergeRequest) int { return 0 }
+	case SortBySize:
+		compare = func(a, b *gitlab.MergeRequest) int {
+			return changesCount(a, options.Details) - changesCount(b, options.Details)
+		}
+	case SortByAuthor:
+		compare = func(a, b *gitlab.MergeRequest) int { return strings.Compare(authorUsername(a), authorUsername(b)) }
+	case SortByProject:
+		compare = func(a, b *gitlab.MergeRequest) int {
+			if byPath := strings.Compare(ProjectPath(a), ProjectPath(b)); byPath != 0 {
+				return byPath
+			}
+			return a.IID - b.IID
+		}
+	case SortByPriority:
+		compare = func(a, b *gitlab.MergeRequest) int {
+			return boolToInt(!isReviewer(a, options.Me)) - boolToInt(!isReviewer(b, options.Me))
+		}
+	}
+
+	sort.SliceStable(mrs, func(i, j int) bool {
+		comparison := compare(mrs[i], mrs[j])
+		if comparison == 0 {
+			comparison = compareTimes(mrs[i].CreatedAt, mrs[j].CreatedAt)
+		}
+		if comparison == 0 {
+			comparison = mrs[i].ID - mrs[j].ID
+		}
+		if reverse {
+			return comparison > 0
+		}
+		return comparison < 0
+	})
+
+	return nil
+}
+
+// compareTimes orders times oldest first, with missing times last.
+func compareTimes(a, b *time.Time) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	default:
+		return a.Compare(*b)
+	}
+}
+
+// changesCount parses GitLab's changes count, which is a string like `12` or `1000+`.
+func changesCount(mr *gitlab.MergeRequest, details map[int]*MergeRequestDetails) int {
+	count := mr.ChangesCount
+	if mrDetails, ok := details[mr.ID]; ok && mrDetails.ChangesCount != "" {
+		count = mrDetails.ChangesCount
+	}
+	changes, err := strconv.Atoi(strings.TrimSuffix(count, "+"))
+	if err != nil {
+		return 0
+	}
+	return changes
+}
+
+func authorUsername(mr *gitlab.MergeRequest) string {
+	if mr.Author == nil {
+		return ""
+	}
+	return mr.Author.Username
+}
+
+func isReviewer(mr *gitlab.MergeRequest, userId int) bool {
+	for _, reviewer := range mr.Reviewers {
+		if reviewer.ID == userId {
+			return true
+		}
+	}
+	return false
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,67 @@
+package mrs
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+func TestSortMergeRequests(t *testing.T) {
+	day := func(d int) *time.Time {
+		date := time.Date(2023, 9, d, 10, 0, 0, 0, time.UTC)
+		return &date
+	}
+	me := &gitlab.BasicUser{ID: 7, Username: "mia"}
+
+	newMrs := func() []*gitlab.MergeRequest {
+		return []*gitlab.MergeRequest{
+			{ID: 1, IID: 4, CreatedAt: day(2), UpdatedAt: day(9), ChangesCount: "30", Author: &gitlab.BasicUser{Username: "carol"}, References: &gitlab.IssueReferences{Full: "acme/web!4"}},
+			{ID: 2, IID: 9, CreatedAt: day(5), UpdatedAt: day(6), ChangesCount: "1000+", Author: &gitlab.BasicUser{Username: "alice"}, References: &gitlab.IssueReferences{Full: "acme/api!9"}, Reviewers: []*gitlab.BasicUser{me}},
+			{ID: 3, IID: 2, CreatedAt: day(1), UpdatedAt: day(7), ChangesCount: "2", Author: &gitlab.BasicUser{Username: "bob"}, References: &gitlab.IssueReferences{Full: "acme/api!2"}},
+			{ID: 4, IID: 1, CreatedAt: day(3), UpdatedAt: day(8), Author: &gitlab.BasicUser{Username: "alice"}, References: &gitlab.IssueReferences{Full: "acme/web!1"}, Reviewers: []*gitlab.BasicUser{me}},
+		}
+	}
+
+	tests := []struct {
+		key     string
+		reverse bool
+		details map[int]*MergeRequestDetails
+		want    []int
+	}{
+		{key: SortByCreated, want: []int{2, 4, 1, 3}},
+		{key: SortByUpdated, want: []int{1, 4, 3, 2}},
+		{key: SortByAge, want: []int{3, 1, 4, 2}},
+		{key: SortByAge, reverse: true, want: []int{2, 4, 1, 3}},
+		{key: SortBySize, details: map[int]*MergeRequestDetails{4: {ChangesCount: "5"}}, want: []int{3, 4, 1, 2}},
+		{key: SortByAuthor, want: []int{4, 2, 3, 1}},
+		{key: SortByProject, want: []int{3, 2, 4, 1}},
+		{key: SortByPriority, want: []int{4, 2, 3, 1}},
+	}
+
+	for _, tt := range tests {
+		name := tt.key
+		if tt.reverse {
+			name += " reversed"
+		}
+		t.Run(name, func(t *testing.T) {
+			mrs := newMrs()
+			if err := SortMergeRequests(mrs, tt.key, tt.reverse, SortOptions{Me: 7, Details: tt.details}); err != nil {
+				t.Fatal(err)
+			}
+
+			var got []int
+			for _, mr := range mrs {
+				got = append(got, mr.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SortMergeRequests() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if err := SortMergeRequests(newMrs(), "stars", false, SortOptions{}); err == nil {
+		t.Error("expected an error for an unknown key")
+	}
+}
@@ -0,0 +1,59 @@
+package mrs
+
+import (
+	"time"
+
+	"github.com/mjburtenshaw/macglab/glab"
+	"github.com/mjburtenshaw/macglab/users"
+	"github.com/xanzy/go-gitlab"
+)
+
+// MergeRequestSource fetches the merge requests `list` filters.
+type MergeRequestSource interface {
+	// FetchGroupMergeRequests fetches open merge requests within a group authored by any of the usernames.
+	FetchGroupMergeRequests(groupId string, usernames []string, shouldIncludeDrafts *bool) ([]*gitlab.MergeRequest, error)
+	// FetchProjectMergeRequests fetches open merge requests within a project authored by any of the usernames.
+	FetchProjectMergeRequests(projectId string, usernames []string, shouldIncludeDrafts *bool) ([]*gitlab.MergeRequest, error)
+	// FetchReviewerMergeRequests fetches open merge requests within a group where the user ID is a reviewer.
+	FetchReviewerMergeRequests(groupId string, userId int, shouldIncludeDrafts *bool) ([]*gitlab.MergeRequest, error)
+	// GetMergeRequestsApprovedByMe fetches open merge requests within a group approved by the user ID.
+	GetMergeRequestsApprovedByMe(groupId string, myId int, shouldIncludeDrafts *bool) ([]*gitlab.MergeRequest, error)
+	// FetchGroupMergeRequestsFinishedSince fetches merge requests within a group authored by the username
+	// that were merged or closed since the given time.
+	FetchGroupMergeRequestsFinishedSince(groupId string, username string, since time.Time) ([]*gitlab.MergeRequest, error)
+	// FetchProjectMergeRequestsFinishedSince fetches merge requests within a project authored by the username
+	// that were merged or closed since the given time.
+	FetchProjectMergeRequestsFinishedSince(projectId string, username string, since time.Time) ([]*gitlab.MergeRequest, error)
+	// FetchGroupMergeRequestsCreatedBetween fetches merge requests within a group authored by the username,
+	// in any state, that were created in [after, before).
+	FetchGroupMergeRequestsCreatedBetween(groupId string, username string, after time.Time, before time.Time) ([]*gitlab.MergeRequest, error)
+	// FetchProjectMergeRequestsCreatedBetween fetches merge requests within a project authored by the username,
+	// in any state, that were created in [after, before).
+	FetchProjectMergeRequestsCreatedBetween(projectId string, username string, after time.Time, before time.Time) ([]*gitlab.MergeRequest, error)
+	// FetchReviewActivity fetches when and by whom a merge request was reviewed.
+	FetchReviewActivity(mr *gitlab.MergeRequest) (*ReviewActivity, error)
+	// FetchMergeRequest fetches a single merge request, in any state.
+	FetchMergeRequest(ref Reference) (*gitlab.MergeRequest, error)
+	// FetchMergeRequestDetails fetches the head pipeline and approvals of a merge request.
+	FetchMergeRequestDetails(projectId int, iid int) (*MergeRequestDetails, error)
+	// FetchProject fetches a project by ID or path, e.g. 123 or platform/api.
+	FetchProject(project string) (*gitlab.Project, error)
+	// FetchNamespaceProjects fetches the unarchived projects in a group, and in its subgroups if includeSubgroups is set.
+	FetchNamespaceProjects(namespace string, includeSubgroups bool) ([]*gitlab.Project, error)
+	// ResolveUser returns the ID of a user given as a username or an ID, e.g. alice or 123.
+	// An empty user is the one the access token belongs to.
+	ResolveUser(user string) (int, error)
+}
+
+// GitlabSource is a MergeRequestSource backed by the GitLab API.
+type GitlabSource struct {
+	client *glab.TGitlabClient
+	users  *users.Cache
+}
+
+var _ MergeRequestSource = (*GitlabSource)(nil)
+
+// NewGitlabSource wraps a GitLab client as a MergeRequestSource.
+func NewGitlabSource(glabClient *glab.TGitlabClient) *GitlabSource {
+	return &GitlabSource{client: glabClient}
+}
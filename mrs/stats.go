@@ -0,0 +1,303 @@
+package mrs
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// OutputCsv writes stats as CSV, one row per metric, author and reviewer.
+const OutputCsv = "csv"
+
+// StatsOutputFormats lists every format WriteStats supports.
+var StatsOutputFormats = []string{OutputTable, OutputJson, OutputCsv}
+
+// DurationStats summarizes how long something took across merge requests, in hours.
+type DurationStats struct {
+	// Count is how many merge requests the durations were measured on.
+	Count     int     `json:"count"`
+	MeanHours float64 `json:"mean_hours"`
+	P50Hours  float64 `json:"p50_hours"`
+	P75Hours  float64 `json:"p75_hours"`
+	P90Hours  float64 `json:"p90_hours"`
+}
+
+// AuthorStats counts the merge requests a user authored.
+type AuthorStats struct {
+	Username      string `json:"username"`
+	MergeRequests int    `json:"merge_requests"`
+	Merged        int    `json:"merged"`
+}
+
+// ReviewerStats counts the merge requests a user was asked to review and approved.
+type ReviewerStats struct {
+	Username string `json:"username"`
+	Assigned int    `json:"assigned"`
+	Approved int    `json:"approved"`
+}
+
+// Stats summarizes review throughput for merge requests created between Since and Until.
+type Stats struct {
+	SchemaVersion     int             `json:"schema_version"`
+	Since             time.Time       `json:"since"`
+	Until             time.Time       `json:"until"`
+	MergeRequests     int             `json:"merge_requests"`
+	Merged            int             `json:"merged"`
+	TimeToFirstReview DurationStats   `json:"time_to_first_review"`
+	TimeToApproval    DurationStats   `json:"time_to_approval"`
+	TimeToMerge       DurationStats   `json:"time_to_merge"`
+	Authors           []AuthorStats   `json:"authors"`
+	Reviewers         []ReviewerStats `json:"reviewers"`
+}
+
+// ValidateStatsOutputFormat returns an error if format isn't one of StatsOutputFormats.
+func ValidateStatsOutputFormat(format string) error {
+	for _, outputFormat := range StatsOutputFormats {
+		if format == outputFormat {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown output %q; expected one of %s", format, strings.Join(StatsOutputFormats, ", "))
+}
+
+// ComputeStats measures review throughput. Every duration starts when the merge request was created.
+// Merge requests missing from activities only count toward authors, reviewers and time to merge.
+func ComputeStats(mrs []*gitlab.MergeRequest, activities map[int]*ReviewActivity, since time.Time, until time.Time) Stats {
+	stats := Stats{
+		SchemaVersion: SchemaVersion,
+		Since:         since,
+		Until:         until,
+		MergeRequests: len(mrs),
+		Authors:       []AuthorStats{},
+		Reviewers:     []ReviewerStats{},
+	}
+
+	var toFirstReview, toApproval, toMerge []time.Duration
+	authors := map[string]*AuthorStats{}
+	reviewers := map[string]*ReviewerStats{}
+
+	reviewer := func(username string) *ReviewerStats {
+		if _, ok := reviewers[username]; !ok {
+			reviewers[username] = &ReviewerStats{Username: username}
+		}
+		return reviewers[username]
+	}
+
+	for _, mr := range mrs {
+		username := authorUsername(mr)
+		if _, ok := authors[username]; !ok {
+			authors[username] = &AuthorStats{Username: username}
+		}
+		authors[username].MergeRequests++
+
+		if mr.State == "merged" {
+			stats.Merged++
+			authors[username].Merged++
+			if elapsed, ok := elapsedBetween(mr.CreatedAt, mr.MergedAt); ok {
+				toMerge = append(toMerge, elapsed)
+			}
+		}
+
+		for _, assigned := range mr.Reviewers {
+			reviewer(assigned.Username).Assigned++
+		}
+
+		activity, ok := activities[mr.ID]
+		if !ok {
+			continue
+		}
+		for _, approver := range activity.Approvers {
+			reviewer(approver).Approved++
+		}
+		if elapsed, ok := elapsedBetween(mr.CreatedAt, activity.FirstReviewAt); ok {
+			toFirstReview = append(toFirstReview, elapsed)
+		}
+		if elapsed, ok := elapsedBetween(mr.CreatedAt, activity.ApprovedAt); ok {
+			toApproval = append(toApproval, elapsed)
+		}
+	}
+
+	stats.TimeToFirstReview = newDurationStats(toFirstReview)
+	stats.TimeToApproval = newDurationStats(toApproval)
+	stats.TimeToMerge = newDurationStats(toMerge)
+
+	for _, author := range authors {
+		stats.Authors = append(stats.Authors, *author)
+	}
+	sort.Slice(stats.Authors, func(i, j int) bool {
+		if stats.Authors[i].MergeRequests != stats.Authors[j].MergeRequests {
+			return stats.Authors[i].MergeRequests > stats.Authors[j].MergeRequests
+		}
+		return stats.Authors[i].Username < stats.Authors[j].Username
+	})
+
+	for _, reviewer := range reviewers {
+		stats.Reviewers = append(stats.Reviewers, *reviewer)
+	}
+	sort.Slice(stats.Reviewers, func(i, j int) bool {
+		a, b := stats.Reviewers[i], stats.Reviewers[j]
+		if a.Assigned+a.Approved != b.Assigned+b.Approved {
+			return a.Assigned+a.Approved > b.Assigned+b.Approved
+		}
+		return a.Username < b.Username
+	})
+
+	return stats
+}
+
+// elapsedBetween returns how long after start end was, if both are known.
+func elapsedBetween(start *time.Time, end *time.Time) (time.Duration, bool) {
+	if start == nil || end == nil {
+		return 0, false
+	}
+	elapsed := end.Sub(*start)
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	return elapsed, true
+}
+
+func newDurationStats(durations []time.Duration) DurationStats {
+	if len(durations) == 0 {
+		return DurationStats{}
+	}
+
+	sorted := append([]time.Duration{}, durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total time.Duration
+	for _, duration := range sorted {
+		total += duration
+	}
+
+	return DurationStats{
+		Count:     len(sorted),
+		MeanHours: roundHours(total / time.Duration(len(sorted))),
+		P50Hours:  roundHours(percentile(sorted, 50)),
+		P75Hours:  roundHours(percentile(sorted, 75)),
+		P90Hours:  roundHours(percentile(sorted, 90)),
+	}
+}
+
+// percentile uses the nearest-rank method on sorted durations.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := int(math.Ceil(float64(p) / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// roundHours converts a duration to hours, to two decimal places.
+func roundHours(duration time.Duration) float64 {
+	return math.Round(duration.Hours()*100) / 100
+}
+
+// WriteStats writes stats as a table, JSON or CSV.
+func WriteStats(w io.Writer, format string, stats Stats) error {
+	switch format {
+	case OutputTable:
+		return writeStatsTable(w, stats)
+	case OutputJson:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(stats)
+	case OutputCsv:
+		return writeStatsCsv(w, stats)
+	default:
+		return ValidateStatsOutputFormat(format)
+	}
+}
+
+func durationMetrics(stats Stats) []struct {
+	name  string
+	stats DurationStats
+} {
+	return []struct {
+		name  string
+		stats DurationStats
+	}{
+		{"time_to_first_review", stats.TimeToFirstReview},
+		{"time_to_approval", stats.TimeToApproval},
+		{"time_to_merge", stats.TimeToMerge},
+	}
+}
+
+func writeStatsTable(w io.Writer, stats Stats) error {
+	tableWriter := tabwriter.NewWriter(w, 0, 0, columnPadding, ' ', 0)
+
+	fmt.Fprintf(tableWriter, "%s created %s – %s, %d merged\n\n",
+		plural(stats.MergeRequests, "MR"), stats.Since.Format("Jan 2 2006"), stats.Until.Format("Jan 2 2006"), stats.Merged)
+
+	fmt.Fprintln(tableWriter, "METRIC\tCOUNT\tMEAN\tP50\tP75\tP90")
+	for _, metric := range durationMetrics(stats) {
+		fmt.Fprintf(tableWriter, "%s\t%d\t%s\t%s\t%s\t%s\n", strings.ReplaceAll(metric.name, "_", " "), metric.stats.Count,
+			formatHours(metric.stats, metric.stats.MeanHours), formatHours(metric.stats, metric.stats.P50Hours),
+			formatHours(metric.stats, metric.stats.P75Hours), formatHours(metric.stats, metric.stats.P90Hours))
+	}
+
+	fmt.Fprintln(tableWriter, "\nAUTHOR\tMRS\tMERGED")
+	for _, author := range stats.Authors {
+		fmt.Fprintf(tableWriter, "@%s\t%d\t%d\n", author.Username, author.MergeRequests, author.Merged)
+	}
+
+	fmt.Fprintln(tableWriter, "\nREVIEWER\tASSIGNED\tAPPROVED")
+	for _, reviewer := range stats.Reviewers {
+		fmt.Fprintf(tableWriter, "@%s\t%d\t%d\n", reviewer.Username, reviewer.Assigned, reviewer.Approved)
+	}
+
+	return tableWriter.Flush()
+}
+
+// formatHours describes hours in their largest sensible unit, e.g. `45m`, `5h` or `2.5d`.
+func formatHours(durationStats DurationStats, hours float64) string {
+	switch {
+	case durationStats.Count == 0:
+		return "-"
+	case hours < 1:
+		return fmt.Sprintf("%dm", int(math.Round(hours*60)))
+	case hours < 48:
+		return fmt.Sprintf("%dh", int(math.Round(hours)))
+	default:
+		return strconv.FormatFloat(math.Round(hours/24*10)/10, 'f', -1, 64) + "d"
+	}
+}
+
+// writeStatsCsv writes one row per metric, author, reviewer and approver so the result charts easily.
+func writeStatsCsv(w io.Writer, stats Stats) error {
+	csvWriter := csv.NewWriter(w)
+	hours := func(value float64) string { return strconv.FormatFloat(value, 'f', -1, 64) }
+
+	rows := [][]string{{"kind", "name", "count", "mean_hours", "p50_hours", "p75_hours", "p90_hours"}}
+	for _, metric := range durationMetrics(stats) {
+		row := []string{"metric", metric.name, strconv.Itoa(metric.stats.Count), "", "", "", ""}
+		if metric.stats.Count > 0 {
+			row = []string{"metric", metric.name, strconv.Itoa(metric.stats.Count),
+				hours(metric.stats.MeanHours), hours(metric.stats.P50Hours), hours(metric.stats.P75Hours), hours(metric.stats.P90Hours)}
+		}
+		rows = append(rows, row)
+	}
+	for _, author := range stats.Authors {
+		rows = append(rows, []string{"author", author.Username, strconv.Itoa(author.MergeRequests), "", "", "", ""})
+	}
+	for _, reviewer := range stats.Reviewers {
+		rows = append(rows, []string{"reviewer", reviewer.Username, strconv.Itoa(reviewer.Assigned), "", "", "", ""})
+	}
+	for _, reviewer := range stats.Reviewers {
+		rows = append(rows, []string{"approver", reviewer.Username, strconv.Itoa(reviewer.Approved), "", "", "", ""})
+	}
+
+	if err := csvWriter.WriteAll(rows); err != nil {
+		return fmt.Errorf("couldn't write CSV: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package mrs
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+func testStats() Stats {
+	return Stats{
+		SchemaVersion:     SchemaVersion,
+		Since:             time.Date(2023, 9, 1, 0, 0, 0, 0, time.UTC),
+		Until:             time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC),
+		MergeRequests:     3,
+		Merged:            1,
+		TimeToFirstReview: DurationStats{Count: 2, MeanHours: 0.5, P50Hours: 0.25, P75Hours: 0.75, P90Hours: 0.75},
+		TimeToApproval:    DurationStats{},
+		TimeToMerge:       DurationStats{Count: 1, MeanHours: 60, P50Hours: 60, P75Hours: 60, P90Hours: 60},
+		Authors:           []AuthorStats{{Username: "alice", MergeRequests: 2, Merged: 1}, {Username: "bob", MergeRequests: 1}},
+		Reviewers:         []ReviewerStats{{Username: "carol", Assigned: 2, Approved: 0}},
+	}
+}
+
+func TestWriteStatsTable(t *testing.T) {
+	var output bytes.Buffer
+	if err := WriteStats(&output, OutputTable, testStats()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `3 MRs created Sep 1 2023 – Oct 1 2023, 1 merged
+
+METRIC                COUNT  MEAN  P50   P75   P90
+time to first review  2      30m   15m   45m   45m
+time to approval      0      -     -     -     -
+time to merge         1      2.5d  2.5d  2.5d  2.5d
+
+AUTHOR  MRS  MERGED
+@alice  2    1
+@bob    1    0
+
+REVIEWER  ASSIGNED  APPROVED
+@carol    2         0
+`
+	if output.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", output.String(), want)
+	}
+}
+
+func TestWriteStatsCsv(t *testing.T) {
+	var output bytes.Buffer
+	if err := WriteStats(&output, OutputCsv, testStats()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `kind,name,count,mean_hours,p50_hours,p75_hours,p90_hours
+metric,time_to_first_review,2,0.5,0.25,0.75,0.75
+metric,time_to_approval,0,,,,
+metric,time_to_merge,1,60,60,60,60
+author,alice,2,,,,
+author,bob,1,,,,
+reviewer,carol,2,,,,
+approver,carol,0,,,,
+`
+	if output.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", output.String(), want)
+	}
+}
+
+func TestNewDurationStats(t *testing.T) {
+	var durations []time.Duration
+	for hours := 10; hours >= 1; hours-- {
+		durations = append(durations, time.Duration(hours)*time.Hour)
+	}
+
+	want := DurationStats{Count: 10, MeanHours: 5.5, P50Hours: 5, P75Hours: 8, P90Hours: 9}
+	if got := newDurationStats(durations); got != want {
+		t.Errorf("newDurationStats() = %+v, want %+v", got, want)
+	}
+}
+
+func TestNewReviewActivity(t *testing.T) {
+	at := func(hour int) *time.Time {
+		t := time.Date(2023, 9, 1, hour, 0, 0, 0, time.UTC)
+		return &t
+	}
+	note := func(authorId int, system bool, body string, hour int) *gitlab.Note {
+		note := &gitlab.Note{System: system, Body: body, CreatedAt: at(hour)}
+		note.Author.ID = authorId
+		return note
+	}
+
+	mr := &gitlab.MergeRequest{Author: &gitlab.BasicUser{ID: 1}, CreatedAt: at(8)}
+	notes := []*gitlab.Note{
+		note(2, true, approvedNote, 15),
+		note(1, false, "Ready for review", 9),
+		note(3, true, "requested review from @bob", 10),
+		note(2, false, "Nice", 12),
+	}
+	approvals := &gitlab.MergeRequestApprovals{ApprovedBy: []*gitlab.MergeRequestApproverUser{{User: &gitlab.BasicUser{Username: "bob"}}}}
+
+	got := NewReviewActivity(mr, notes, approvals)
+	want := &ReviewActivity{FirstReviewAt: at(12), ApprovedAt: at(15), Approvers: []string{"bob"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NewReviewActivity() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,181 @@
+package mrs
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// Columns supported by WriteTable.
+const (
+	ColumnProject   = "project"
+	ColumnIid       = "iid"
+	ColumnTitle     = "title"
+	ColumnAuthor    = "author"
+	ColumnAge       = "age"
+	ColumnPipeline  = "pipeline"
+	ColumnApprovals = "approvals"
+	ColumnStatus    = "status"
+)
+
+// DefaultColumns lists every column in the order `--output table` prints them.
+var DefaultColumns = []string{ColumnProject, ColumnIid, ColumnTitle, ColumnAuthor, ColumnAge, ColumnPipeline, ColumnApprovals, ColumnStatus}
+
+// minTitleWidth stops titles from being truncated into nothing on narrow terminals.
+const minTitleWidth = 10
+
+// columnPadding is the space between columns.
+const columnPadding = 2
+
+// TableOptions controls how WriteTable renders merge requests.
+type TableOptions struct {
+	// Columns to print, in order.
+	Columns []string
+	// NoHeader omits the header row, e.g. for piping.
+	NoHeader bool
+	// Width is the terminal width titles are truncated to fit. Zero means don't truncate.
+	Width int
+}
+
+// ParseColumns parses a CSV of column names. An empty CSV means DefaultColumns.
+func ParseColumns(columnsRaw string) ([]string, error) {
+	columnsRaw = strings.ReplaceAll(columnsRaw, " ", "")
+	if columnsRaw == "" {
+		return DefaultColumns, nil
+	}
+
+	var columns []string
+	for _, column := range strings.Split(columnsRaw, ",") {
+		if !isColumn(column) {
+			return nil, fmt.Errorf("unknown column %q; expected any of %s", column, strings.Join(DefaultColumns, ", "))
+		}
+		columns = append(columns, column)
+	}
+
+	return columns, nil
+}
+
+func isColumn(column string) bool {
+	for _, defaultColumn := range DefaultColumns {
+		if column == defaultColumn {
+			return true
+		}
+	}
+	return false
+}
+
+// NeedsDetails reports whether any of the columns require MergeRequestDetails.
+func NeedsDetails(columns []string) bool {
+	for _, column := range columns {
+		if column == ColumnPipeline || column == ColumnApprovals {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteTable writes merge requests as aligned columns. details may be nil, in which case
+// columns that need them are printed as `-`.
+func WriteTable(w io.Writer, mrs []*gitlab.MergeRequest, details map[int]*MergeRequestDetails, options TableOptions) error {
+	var rows [][]string
+	if !options.NoHeader {
+		header := make([]string, len(options.Columns))
+		for i, column := range options.Columns {
+			header[i] = strings.ToUpper(column)
+		}
+		rows = append(rows, header)
+	}
+
+	for _, mr := range mrs {
+		row := make([]string, len(options.Columns))
+		for i, column := range options.Columns {
+			row[i] = tableCell(mr, details[mr.ID], column)
+		}
+		rows = append(rows, row)
+	}
+
+	truncateTitles(rows, options)
+
+	tableWriter := tabwriter.NewWriter(w, 0, 0, columnPadding, ' ', 0)
+	for _, row := range rows {
+		if _, err := fmt.Fprintln(tableWriter, strings.Join(row, "\t")); err != nil {
+			return err
+		}
+	}
+
+	return tableWriter.Flush()
+}
+
+func tableCell(mr *gitlab.MergeRequest, details *MergeRequestDetails, column string) string {
+	switch column {
+	case ColumnProject:
+		return ProjectPath(mr)
+	case ColumnIid:
+		return fmt.Sprintf("!%d", mr.IID)
+	case ColumnTitle:
+		// Tabs would break the alignment.
+		return strings.ReplaceAll(mr.Title, "\t", " ")
+	case ColumnAuthor:
+		if mr.Author == nil {
+			return "-"
+		}
+		return "@" + mr.Author.Username
+	case ColumnAge:
+		return RelativeTime(mr.CreatedAt)
+	case ColumnPipeline:
+		if details == nil || details.PipelineStatus == "" {
+			return "-"
+		}
+		return details.PipelineStatus
+	case ColumnApprovals:
+		if details == nil {
+			return "-"
+		}
+		return fmt.Sprintf("%d/%d", details.Approvals, details.ApprovalsRequired)
+	case ColumnStatus:
+		return mr.DetailedMergeStatus
+	default:
+		return ""
+	}
+}
+
+// truncateTitles shortens the title column so each row fits within options.Width.
+func truncateTitles(rows [][]string, options TableOptions) {
+	if options.Width <= 0 {
+		return
+	}
+
+	titleIndex := -1
+	widths := make([]int, len(options.Columns))
+	for _, row := range rows {
+		for i, cell := range row {
+			if cellWidth := len([]rune(cell)); cellWidth > widths[i] {
+				widths[i] = cellWidth
+			}
+		}
+	}
+
+	otherWidth := 0
+	for i, column := range options.Columns {
+		if column == ColumnTitle {
+			titleIndex = i
+			continue
+		}
+		otherWidth += widths[i]
+	}
+	if titleIndex == -1 {
+		return
+	}
+
+	titleWidth := options.Width - otherWidth - columnPadding*(len(options.Columns)-1)
+	if titleWidth < minTitleWidth {
+		titleWidth = minTitleWidth
+	}
+
+	for _, row := range rows {
+		row[titleIndex] = Truncate(titleWidth, row[titleIndex])
+	}
+}
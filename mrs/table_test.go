@@ -0,0 +1,100 @@
+package mrs
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/mjburtenshaw/macglab/fakegitlab"
+	"github.com/xanzy/go-gitlab"
+)
+
+func TestParseColumns(t *testing.T) {
+	columns, err := ParseColumns("title, iid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(columns) != 2 || columns[0] != ColumnTitle || columns[1] != ColumnIid {
+		t.Errorf("ParseColumns() = %v", columns)
+	}
+
+	if columns, _ := ParseColumns(""); len(columns) != len(DefaultColumns) {
+		t.Errorf("ParseColumns(\"\") = %v, want %v", columns, DefaultColumns)
+	}
+
+	if _, err := ParseColumns("title,size"); err == nil {
+		t.Error("expected an error for an unknown column")
+	}
+}
+
+func TestWriteTable(t *testing.T) {
+	now = func() time.Time { return time.Date(2023, 9, 4, 10, 0, 0, 0, time.UTC) }
+	t.Cleanup(func() { now = time.Now })
+
+	createdAt := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+	fixture := fakegitlab.Fixture{
+		MergeRequest: gitlab.MergeRequest{
+			ID:                  101,
+			IID:                 12,
+			ProjectID:           1,
+			Title:               "Add a widget API with a rather long title",
+			Author:              &gitlab.BasicUser{Username: "alice"},
+			CreatedAt:           &createdAt,
+			DetailedMergeStatus: "not_approved",
+			References:          &gitlab.IssueReferences{Full: "acme/api!12"},
+			HeadPipeline:        &gitlab.Pipeline{Status: "failed"},
+		},
+		GroupId:           "42",
+		ApprovedByIds:     []int{7},
+		ApprovalsRequired: 2,
+	}
+
+	server := fakegitlab.NewServer([]fakegitlab.Fixture{fixture})
+	t.Cleanup(server.Close)
+	glabClient, err := server.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mr := fixture.MergeRequest
+	mrs := []*gitlab.MergeRequest{&mr}
+	details, err := FetchDetails(NewGitlabSource(glabClient), mrs, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		options TableOptions
+		want    string
+	}{
+		{
+			name:    "every column",
+			options: TableOptions{Columns: DefaultColumns},
+			want: "PROJECT   IID  TITLE                                      AUTHOR  AGE  PIPELINE  APPROVALS  STATUS\n" +
+				"acme/api  !12  Add a widget API with a rather long title  @alice  3d   failed    1/2        not_approved\n",
+		},
+		{
+			name:    "chosen columns without a header",
+			options: TableOptions{Columns: []string{ColumnAuthor, ColumnIid}, NoHeader: true},
+			want:    "@alice  !12\n",
+		},
+		{
+			name:    "truncates titles to fit",
+			options: TableOptions{Columns: []string{ColumnIid, ColumnTitle}, NoHeader: true, Width: 20},
+			want:    "!12  Add a widget A…\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var output bytes.Buffer
+			if err := WriteTable(&output, mrs, details, tt.options); err != nil {
+				t.Fatal(err)
+			}
+			if output.String() != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", output.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package mrs
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// DefaultFormat is the template `list` prints each merge request with.
+const DefaultFormat = "@{{.Author.Username}}: {{.WebURL}}"
+
+// now is swapped out in tests.
+var now = time.Now
+
+var ansiColors = map[string]string{
+	"black":   "30",
+	"red":     "31",
+	"green":   "32",
+	"yellow":  "33",
+	"blue":    "34",
+	"magenta": "35",
+	"cyan":    "36",
+	"white":   "37",
+	"gray":    "90",
+	"bold":    "1",
+}
+
+// TemplateFuncs are the helper functions available to --format templates.
+var TemplateFuncs = template.FuncMap{
+	"ago":       RelativeTime,
+	"color":     Colorize,
+	"join":      Join,
+	"padRight":  PadRight,
+	"truncate":  Truncate,
+	"usernames": Usernames,
+}
+
+// ParseFormat parses a --format value into a template. A value like `@name` refers to a
+// named template from the config. Escaped tabs and newlines (`\t`, `\n`) outside actions are
+// unescaped so they can be typed in the shell; inside actions, e.g. `{{join "\n" .Labels}}`, Go's
+// own string literals handle them.
+func ParseFormat(format string, namedTemplates map[string]string) (*template.Template, error) {
+	if strings.HasPrefix(format, "@") && !strings.Contains(format, "{{") {
+		name := strings.TrimPrefix(format, "@")
+		namedTemplate, ok := namedTemplates[name]
+		if !ok {
+			return nil, fmt.Errorf("couldn't find a template named %q in your config", name)
+		}
+		format = namedTemplate
+	}
+
+	format = unescapeText(format)
+
+	tmpl, err := template.New("format").Funcs(TemplateFuncs).Parse(format)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't parse format: %w", err)
+	}
+
+	return tmpl, nil
+}
+
+// unescapeText unescapes `\t` and `\n` in the text between a format's actions, leaving actions as they are.
+func unescapeText(format string) string {
+	unescaper := strings.NewReplacer(`\t`, "\t", `\n`, "\n")
+
+	var unescaped strings.Builder
+	for {
+		start := strings.Index(format, "{{")
+		if start < 0 {
+			unescaped.WriteString(unescaper.Replace(format))
+			return unescaped.String()
+		}
+		unescaped.WriteString(unescaper.Replace(format[:start]))
+
+		end := strings.Index(format[start:], "}}")
+		if end < 0 {
+			// Leave an unclosed action for the template parser to report.
+			unescaped.WriteString(format[start:])
+			return unescaped.String()
+		}
+		end += start + len("}}")
+		unescaped.WriteString(format[start:end])
+		format = format[end:]
+	}
+}
+
+// WriteTemplate executes tmpl for each merge request, one per line.
+func WriteTemplate(w io.Writer, tmpl *template.Template, mrs []*gitlab.MergeRequest) error {
+	for _, mr := range mrs {
+		if err := tmpl.Execute(w, mr); err != nil {
+			return fmt.Errorf("couldn't execute format for %s: %w", mr.WebURL, err)
+		}
+		if _, err := io.WriteString(w, "\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RelativeTime describes how long ago t was in its largest whole unit, e.g. `3d`.
+func RelativeTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	elapsed := now().Sub(*t)
+	switch {
+	case elapsed < time.Minute:
+		return "now"
+	case elapsed < time.Hour:
+		return fmt.Sprintf("%dm", int(elapsed.Minutes()))
+	case elapsed < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(elapsed.Hours()))
+	case elapsed < 7*24*time.Hour:
+		return fmt.Sprintf("%dd", int(elapsed.Hours()/24))
+	default:
+		return fmt.Sprintf("%dw", int(elapsed.Hours()/(24*7)))
+	}
+}
+
+// Colorize wraps s in the named ANSI colour. It returns s unchanged for unknown colours
+// or when the NO_COLOR environment variable is set.
+func Colorize(color string, s string) string {
+	code, ok := ansiColors[color]
+	if !ok || os.Getenv("NO_COLOR") != "" {
+		return s
+	}
+	return fmt.Sprintf("\x1b[%sm%s\x1b[0m", code, s)
+}
+
+// Truncate shortens s to at most length runes, ending with an ellipsis if it was cut.
+func Truncate(length int, s string) string {
+	runes := []rune(s)
+	if len(runes) <= length {
+		return s
+	}
+	if length < 1 {
+		return ""
+	}
+	return string(runes[:length-1]) + "…"
+}
+
+// PadRight pads s with spaces to at least length runes.
+func PadRight(length int, s string) string {
+	padding := length - len([]rune(s))
+	if padding <= 0 {
+		return s
+	}
+	return s + strings.Repeat(" ", padding)
+}
+
+// Join joins elems with sep. It takes sep first so it can be piped into, e.g. `{{.Labels | join ", "}}`.
+func Join(sep string, elems []string) string {
+	return strings.Join(elems, sep)
+}
+
+// Usernames returns the usernames of users, e.g. `{{.Reviewers | usernames | join ", "}}`.
+func Usernames(users []*gitlab.BasicUser) []string {
+	usernames := []string{}
+	for _, user := range users {
+		usernames = append(usernames, user.Username)
+	}
+	return usernames
+}
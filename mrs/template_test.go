@@ -0,0 +1,71 @@
+package mrs
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+func TestParseFormat(t *testing.T) {
+	now = func() time.Time { return time.Date(2023, 9, 4, 10, 0, 0, 0, time.UTC) }
+	t.Cleanup(func() { now = time.Now })
+	t.Setenv("NO_COLOR", "1")
+
+	createdAt := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+	mr := &gitlab.MergeRequest{
+		Title:     "Add a much longer widget API title",
+		Author:    &gitlab.BasicUser{Username: "alice"},
+		Reviewers: []*gitlab.BasicUser{{Username: "mia"}, {Username: "bob"}},
+		Labels:    gitlab.Labels{"backend", "api"},
+		CreatedAt: &createdAt,
+		WebURL:    "https://gitlab.example.com/acme/api/-/merge_requests/1",
+	}
+	namedTemplates := map[string]string{
+		"short":  "{{.Author.Username}} {{ago .CreatedAt}}",
+		"labels": `{{.Author.Username}}\n{{join "\n" .Labels}}`,
+	}
+
+	tests := []struct {
+		name    string
+		format  string
+		want    string
+		wantErr bool
+	}{
+		{name: "fields and escaped tab", format: `{{.Author.Username}}\t{{.WebURL}}`, want: "alice\thttps://gitlab.example.com/acme/api/-/merge_requests/1\n"},
+		{name: "named template", format: "@short", want: "alice 3d\n"},
+		{name: "unknown named template", format: "@long", wantErr: true},
+		{name: "literal at sign", format: "@{{.Author.Username}}", want: "@alice\n"},
+		{name: "truncate", format: "{{truncate 10 .Title}}", want: "Add a muc…\n"},
+		{name: "padRight", format: "{{padRight 8 .Author.Username}}|", want: "alice   |\n"},
+		{name: "join", format: `{{.Labels | join ","}} {{.Reviewers | usernames | join ", "}}`, want: "backend,api mia, bob\n"},
+		{name: "escapes inside actions are Go's", format: `{{.Author.Username}}\t{{join "\n" .Labels}}`, want: "alice\tbackend\napi\n"},
+		{name: "named template with escapes inside actions", format: "@labels", want: "alice\nbackend\napi\n"},
+		{name: "color respects NO_COLOR", format: `{{color "red" .Author.Username}}`, want: "alice\n"},
+		{name: "syntax error", format: "{{.Title", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := ParseFormat(tt.format, namedTemplates)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var output bytes.Buffer
+			if err := WriteTemplate(&output, tmpl, []*gitlab.MergeRequest{mr}); err != nil {
+				t.Fatal(err)
+			}
+			if output.String() != tt.want {
+				t.Errorf("got %q, want %q", output.String(), tt.want)
+			}
+		})
+	}
+}
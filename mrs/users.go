@@ -0,0 +1,61 @@
+package mrs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mjburtenshaw/macglab/users"
+	"github.com/xanzy/go-gitlab"
+)
+
+// WithUserCache remembers the usernames ResolveUser looks up in cache, and looks there first.
+func (source *GitlabSource) WithUserCache(cache *users.Cache) *GitlabSource {
+	source.users = cache
+	return source
+}
+
+// ResolveUser implements MergeRequestSource.
+func (source *GitlabSource) ResolveUser(user string) (int, error) {
+	user = strings.TrimPrefix(strings.TrimSpace(user), "@")
+	instance := source.client.BaseURL().String()
+
+	if user == "" {
+		currentUser, _, err := source.client.Users.CurrentUser()
+		if err != nil {
+			return 0, fmt.Errorf("couldn't find who the access token belongs to: %w", err)
+		}
+		source.rememberUser(instance, currentUser.Username, currentUser.ID)
+		return currentUser.ID, nil
+	}
+
+	if id, err := strconv.Atoi(user); err == nil {
+		return id, nil
+	}
+
+	if source.users != nil {
+		if id, ok := source.users.Id(instance, user); ok {
+			return id, nil
+		}
+	}
+
+	matches, _, err := source.client.Users.ListUsers(&gitlab.ListUsersOptions{Username: gitlab.String(user)})
+	if err != nil {
+		return 0, fmt.Errorf("couldn't look up user %s: %w", user, err)
+	}
+	for _, match := range matches {
+		if strings.EqualFold(match.Username, user) {
+			source.rememberUser(instance, match.Username, match.ID)
+			return match.ID, nil
+		}
+	}
+
+	return 0, fmt.Errorf("there's no GitLab user named %s", user)
+}
+
+// rememberUser adds a username's ID to the user cache, if there is one.
+func (source *GitlabSource) rememberUser(instance string, username string, id int) {
+	if source.users != nil {
+		source.users.Add(instance, username, id)
+	}
+}
@@ -0,0 +1,169 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/mjburtenshaw/macglab/config"
+	"github.com/mjburtenshaw/macglab/mrs"
+)
+
+// DefaultCommandTemplate is the body a command notifier sends when it has no template.
+const DefaultCommandTemplate = "{{.MergeRequest.Title}} by @{{.MergeRequest.Author.Username}}"
+
+// defaultCommand is what a command notifier runs when its config doesn't say.
+const defaultCommand = "notify-send"
+
+// defaultNotifySendArgs suit notify-send, which takes a title and a body.
+var defaultNotifySendArgs = []string{"--app-name=macglab", "{{.Title}}", "{{.Body}}\n{{.URL}}"}
+
+// defaultCommandArgs suit any other command.
+var defaultCommandArgs = []string{"{{.Title}}", "{{.Body}}", "{{.URL}}"}
+
+// commandTimeout is how long a command notifier's command may run.
+const commandTimeout = 10 * time.Second
+
+// Message is what a command notifier passes to its command.
+type Message struct {
+	Title string
+	Body  string
+	URL   string
+	Event Event
+}
+
+// commandInput is what a command notifier writes to its command's stdin.
+type commandInput struct {
+	Title        string                 `json:"title"`
+	Body         string                 `json:"body"`
+	Url          string                 `json:"url"`
+	Event        string                 `json:"event"`
+	MergeRequest mrs.MergeRequestRecord `json:"merge_request"`
+}
+
+// CommandNotifier runs an executable for each event, e.g. to raise a desktop notification.
+// The executable gets the title, body and URL as arguments, or as JSON on stdin.
+type CommandNotifier struct {
+	eventFilter
+	name    string
+	command string
+	args    []*template.Template
+	stdin   bool
+	body    *template.Template
+}
+
+// NewCommandNotifier builds a command notifier.
+func NewCommandNotifier(notifierConfig config.NotifierConfig) (*CommandNotifier, error) {
+	filter, err := newEventFilter(notifierConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	// Whether the command is installed is checked when it runs, so the config stays valid on
+	// machines without it.
+	command := notifierConfig.Command
+	if command == "" && runtime.GOOS == "linux" {
+		command = defaultCommand
+	}
+
+	argTemplates := notifierConfig.Args
+	if argTemplates == nil && !notifierConfig.Stdin {
+		argTemplates = defaultCommandArgs
+		if command == defaultCommand {
+			argTemplates = defaultNotifySendArgs
+		}
+	}
+
+	notifier := &CommandNotifier{
+		eventFilter: filter,
+		name:        notifierConfig.Name,
+		command:     command,
+		stdin:       notifierConfig.Stdin,
+	}
+
+	for i, argTemplate := range argTemplates {
+		tmpl, err := parseTemplate(fmt.Sprintf("%s arg %d", notifierConfig.Name, i+1), argTemplate)
+		if err != nil {
+			return nil, err
+		}
+		notifier.args = append(notifier.args, tmpl)
+	}
+
+	bodyTemplate := notifierConfig.Template
+	if bodyTemplate == "" {
+		bodyTemplate = DefaultCommandTemplate
+	}
+	notifier.body, err = parseTemplate(notifierConfig.Name, bodyTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	return notifier, nil
+}
+
+// Name implements Notifier.
+func (notifier *CommandNotifier) Name() string {
+	return notifier.name
+}
+
+// Notify implements Notifier.
+func (notifier *CommandNotifier) Notify(event Event) error {
+	if notifier.command == "" {
+		return errors.New("a command notifier needs a command outside Linux")
+	}
+	if _, err := exec.LookPath(notifier.command); err != nil {
+		return fmt.Errorf("couldn't find %s: %w", notifier.command, err)
+	}
+
+	var body bytes.Buffer
+	if err := notifier.body.Execute(&body, event); err != nil {
+		return fmt.Errorf("couldn't execute template: %w", err)
+	}
+
+	message := Message{
+		Title: event.Summary(),
+		Body:  body.String(),
+		URL:   event.MergeRequest.WebURL,
+		Event: event,
+	}
+
+	var args []string
+	for _, tmpl := range notifier.args {
+		var arg bytes.Buffer
+		if err := tmpl.Execute(&arg, message); err != nil {
+			return fmt.Errorf("couldn't execute template: %w", err)
+		}
+		args = append(args, arg.String())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, notifier.command, args...)
+	if notifier.stdin {
+		input, err := marshalJson(commandInput{
+			Title:        message.Title,
+			Body:         message.Body,
+			Url:          message.URL,
+			Event:        event.Kind,
+			MergeRequest: mrs.NewMergeRequestRecord(event.MergeRequest),
+		})
+		if err != nil {
+			return fmt.Errorf("couldn't marshal event: %w", err)
+		}
+		cmd.Stdin = bytes.NewReader(input)
+	}
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s failed: %w: %s", notifier.command, err, strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
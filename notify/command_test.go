@@ -0,0 +1,163 @@
+package notify
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/mjburtenshaw/macglab/config"
+	"github.com/xanzy/go-gitlab"
+)
+
+// writeStub writes a script that records its arguments, one per line, then its stdin.
+func writeStub(t *testing.T) (stubUrl string, outputUrl string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("stub scripts need a POSIX shell")
+	}
+
+	dir := t.TempDir()
+	stubUrl = filepath.Join(dir, "notify")
+	outputUrl = filepath.Join(dir, "output")
+
+	script := "#!/bin/sh\nfor arg in \"$@\"; do echo \"arg: $arg\"; done > " + outputUrl + "\ncat >> " + outputUrl + "\n"
+	if err := os.WriteFile(stubUrl, []byte(script), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	return stubUrl, outputUrl
+}
+
+func TestCommandNotifier(t *testing.T) {
+	tests := []struct {
+		name   string
+		config config.NotifierConfig
+		want   string
+	}{
+		{
+			name:   "passes the title, body and URL as arguments",
+			config: config.NotifierConfig{},
+			want: `arg: New MR for review
+arg: Fix "quotes" by @alice
+arg: https://gitlab.example.com/acme/web/-/merge_requests/3
+`,
+		},
+		{
+			name:   "renders custom arguments",
+			config: config.NotifierConfig{Args: []string{"--urgency=low", "{{.Title}}: {{.Event.MergeRequest.IID}}"}, Template: "unused"},
+			want: `arg: --urgency=low
+arg: New MR for review: 3
+`,
+		},
+		{
+			name:   "sends JSON on stdin",
+			config: config.NotifierConfig{Stdin: true},
+			want:   `{"title":"New MR for review","body":"Fix \"quotes\" by @alice","url":"https://gitlab.example.com/acme/web/-/merge_requests/3","event":"joined_queue","merge_request":{"schema_version":1,"project_path":"acme/web","iid":3,"title":"Fix \"quotes\"","author":{"id":0,"username":"alice","name":""},"reviewers":[],"labels":[],"draft":false,"detailed_merge_status":"","created_at":null,"updated_at":null,"web_url":"https://gitlab.example.com/acme/web/-/merge_requests/3"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stubUrl, outputUrl := writeStub(t)
+
+			tt.config.Name = "desktop"
+			tt.config.Type = TypeCommand
+			tt.config.Command = stubUrl
+			notifier, err := NewCommandNotifier(tt.config)
+			if err != nil {
+				t.Fatalf("NewCommandNotifier() error = %v", err)
+			}
+
+			if err := notifier.Notify(testEvent()); err != nil {
+				t.Fatalf("Notify() error = %v", err)
+			}
+
+			output, err := os.ReadFile(outputUrl)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(output) != tt.want {
+				t.Errorf("command got:\n%s\nwant:\n%s", output, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommandNotifierFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("stub scripts need a POSIX shell")
+	}
+
+	stubUrl := filepath.Join(t.TempDir(), "notify")
+	if err := os.WriteFile(stubUrl, []byte("#!/bin/sh\necho 'no display' >&2\nexit 1\n"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	notifier, err := NewCommandNotifier(config.NotifierConfig{Name: "desktop", Type: TypeCommand, Command: stubUrl})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = notifier.Notify(testEvent())
+	if err == nil || !strings.Contains(err.Error(), "no display") {
+		t.Errorf("Notify() error = %v, want the command's output", err)
+	}
+}
+
+func TestCommandNotifierMissingCommand(t *testing.T) {
+	notifier, err := NewCommandNotifier(config.NotifierConfig{Name: "desktop", Type: TypeCommand, Command: "macglab-no-such-command"})
+	if err != nil {
+		t.Fatalf("NewCommandNotifier() error = %v, want the command looked up when it runs", err)
+	}
+
+	err = notifier.Notify(testEvent())
+	if err == nil || !strings.Contains(err.Error(), "couldn't find macglab-no-such-command") {
+		t.Errorf("Notify() error = %v, want it to say the command is missing", err)
+	}
+}
+
+func TestQueueEvents(t *testing.T) {
+	const me = 7
+
+	tests := []struct {
+		name string
+		mr   *gitlab.MergeRequest
+		want []string
+	}{
+		{
+			name: "someone else's MR",
+			mr:   &gitlab.MergeRequest{Author: &gitlab.BasicUser{ID: 1}, DetailedMergeStatus: "mergeable"},
+			want: []string{EventJoinedQueue},
+		},
+		{
+			name: "my review was requested",
+			mr:   &gitlab.MergeRequest{Author: &gitlab.BasicUser{ID: 1}, Reviewers: []*gitlab.BasicUser{{ID: 2}, {ID: me}}},
+			want: []string{EventJoinedQueue, EventReviewRequested},
+		},
+		{
+			name: "my MR is mergeable",
+			mr:   &gitlab.MergeRequest{Author: &gitlab.BasicUser{ID: me}, DetailedMergeStatus: "mergeable"},
+			want: []string{EventJoinedQueue, EventMergeable},
+		},
+		{
+			name: "my MR isn't mergeable yet",
+			mr:   &gitlab.MergeRequest{Author: &gitlab.BasicUser{ID: me}, DetailedMergeStatus: "not_approved"},
+			want: []string{EventJoinedQueue},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var kinds []string
+			for _, event := range QueueEvents(tt.mr, me) {
+				kinds = append(kinds, event.Kind)
+			}
+			if !reflect.DeepEqual(kinds, tt.want) {
+				t.Errorf("QueueEvents() = %v, want %v", kinds, tt.want)
+			}
+		})
+	}
+}
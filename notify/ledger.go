@@ -0,0 +1,98 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ledgerRetention is how long the ledger remembers an announcement after its event was last
+// seen, e.g. after its MR left the queue. Forgetting keeps the file from growing forever.
+const ledgerRetention = 90 * 24 * time.Hour
+
+// Ledger remembers which notifier announced which event, so nothing is announced twice,
+// even across runs.
+type Ledger struct {
+	url string
+
+	mu      sync.Mutex
+	entries map[string]time.Time
+}
+
+// LoadLedger reads the ledger at ledgerUrl. A missing file is an empty ledger.
+func LoadLedger(ledgerUrl string) (*Ledger, error) {
+	ledger := &Ledger{url: ledgerUrl, entries: map[string]time.Time{}}
+
+	data, err := os.ReadFile(ledgerUrl)
+	if os.IsNotExist(err) {
+		return ledger, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read %s: %w", ledgerUrl, err)
+	}
+
+	if err := json.Unmarshal(data, &ledger.entries); err != nil {
+		return nil, fmt.Errorf("couldn't unmarshal %s: %w", ledgerUrl, err)
+	}
+
+	return ledger, nil
+}
+
+func ledgerKey(notifierName string, event Event) string {
+	return fmt.Sprintf("%s %s", notifierName, event.key())
+}
+
+// Has reports whether the notifier already announced the event.
+func (ledger *Ledger) Has(notifierName string, event Event) bool {
+	ledger.mu.Lock()
+	defer ledger.mu.Unlock()
+	_, ok := ledger.entries[ledgerKey(notifierName, event)]
+	return ok
+}
+
+// Add records that the notifier announced the event, or that an event it announced is still happening.
+func (ledger *Ledger) Add(notifierName string, event Event) {
+	ledger.mu.Lock()
+	defer ledger.mu.Unlock()
+	ledger.entries[ledgerKey(notifierName, event)] = time.Now()
+}
+
+// Save writes the ledger, forgetting announcements of events not seen for ledgerRetention.
+func (ledger *Ledger) Save() error {
+	ledger.mu.Lock()
+	defer ledger.mu.Unlock()
+
+	for key, announcedAt := range ledger.entries {
+		if time.Since(announcedAt) > ledgerRetention {
+			delete(ledger.entries, key)
+		}
+	}
+
+	data, err := json.MarshalIndent(ledger.entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("couldn't marshal ledger: %w", err)
+	}
+
+	tempFile, err := os.CreateTemp(filepath.Dir(ledger.url), filepath.Base(ledger.url)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("couldn't save %s: %w", ledger.url, err)
+	}
+	defer os.Remove(tempFile.Name())
+
+	if _, err := tempFile.Write(data); err != nil {
+		tempFile.Close()
+		return fmt.Errorf("couldn't save %s: %w", ledger.url, err)
+	}
+	if err := tempFile.Close(); err != nil {
+		return fmt.Errorf("couldn't save %s: %w", ledger.url, err)
+	}
+
+	if err := os.Rename(tempFile.Name(), ledger.url); err != nil {
+		return fmt.Errorf("couldn't save %s: %w", ledger.url, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,223 @@
+// Package notify announces merge requests to chat, desktops and anything else that can take a message.
+package notify
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/mjburtenshaw/macglab/config"
+	"github.com/mjburtenshaw/macglab/mrs"
+	"github.com/xanzy/go-gitlab"
+)
+
+// Kinds of Event.
+const (
+	// EventJoinedQueue is sent when an MR enters the list queue.
+	EventJoinedQueue = "joined_queue"
+	// EventReviewRequested is sent when an MR you're a reviewer of enters the queue.
+	EventReviewRequested = "review_requested"
+	// EventMergeable is sent when an MR you authored becomes ready to merge.
+	EventMergeable = "mergeable"
+)
+
+// EventKinds lists every kind of event notifiers can subscribe to.
+var EventKinds = []string{EventJoinedQueue, EventReviewRequested, EventMergeable}
+
+// Types of notifier supported in the config.
+const (
+	TypeCommand = "command"
+	TypeSlack   = "slack"
+	TypeWebhook = "webhook"
+)
+
+// defaultEvents are what each type of notifier announces when its config doesn't say.
+// Chat notifiers tell a team about the queue; commands tell you about your own work.
+var defaultEvents = map[string][]string{
+	TypeCommand: {EventReviewRequested, EventMergeable},
+	TypeSlack:   {EventJoinedQueue},
+	TypeWebhook: {EventJoinedQueue},
+}
+
+// Event is something worth announcing about a merge request.
+type Event struct {
+	Kind         string
+	MergeRequest *gitlab.MergeRequest
+}
+
+// Summary describes the event in a few words, e.g. for a message title.
+func (event Event) Summary() string {
+	switch event.Kind {
+	case EventJoinedQueue:
+		return "New MR for review"
+	case EventReviewRequested:
+		return "Your review was requested"
+	case EventMergeable:
+		return "Your MR is ready to merge"
+	default:
+		return "MR update"
+	}
+}
+
+// key identifies the event in the ledger. An MR becomes mergeable again after new
+// commits, so mergeable events are per commit.
+func (event Event) key() string {
+	if event.Kind == EventMergeable {
+		return fmt.Sprintf("%s %s@%s", event.Kind, event.MergeRequest.WebURL, event.MergeRequest.SHA)
+	}
+	return fmt.Sprintf("%s %s", event.Kind, event.MergeRequest.WebURL)
+}
+
+// QueueEvents lists the events implied by an MR in the queue of the user with ID me.
+func QueueEvents(mr *gitlab.MergeRequest, me int) []Event {
+	events := []Event{{Kind: EventJoinedQueue, MergeRequest: mr}}
+
+	for _, reviewer := range mr.Reviewers {
+		if me != 0 && reviewer.ID == me {
+			events = append(events, Event{Kind: EventReviewRequested, MergeRequest: mr})
+			break
+		}
+	}
+
+	if IsMine(mr, me) && mr.DetailedMergeStatus == mrs.MergeableStatus {
+		events = append(events, Event{Kind: EventMergeable, MergeRequest: mr})
+	}
+
+	return events
+}
+
+// IsMine reports whether the user with ID me authored the MR.
+func IsMine(mr *gitlab.MergeRequest, me int) bool {
+	return me != 0 && mr.Author != nil && mr.Author.ID == me
+}
+
+// Notifier announces events somewhere.
+type Notifier interface {
+	// Name identifies the notifier in the config and in the ledger of what it announced.
+	Name() string
+	// Wants reports whether the notifier announces events of a kind.
+	Wants(kind string) bool
+	// Notify announces an event.
+	Notify(event Event) error
+}
+
+// eventFilter implements Notifier.Wants for the kinds of events in a notifier's config.
+type eventFilter map[string]bool
+
+func newEventFilter(notifierConfig config.NotifierConfig) (eventFilter, error) {
+	kinds := notifierConfig.Events
+	if len(kinds) == 0 {
+		kinds = defaultEvents[notifierConfig.Type]
+	}
+
+	filter := eventFilter{}
+	for _, kind := range kinds {
+		if !isEventKind(kind) {
+			return nil, fmt.Errorf("unknown event %q; expected one of %s", kind, strings.Join(EventKinds, ", "))
+		}
+		filter[kind] = true
+	}
+
+	return filter, nil
+}
+
+func isEventKind(kind string) bool {
+	for _, eventKind := range EventKinds {
+		if kind == eventKind {
+			return true
+		}
+	}
+	return false
+}
+
+// Wants implements Notifier.
+func (filter eventFilter) Wants(kind string) bool {
+	return filter[kind]
+}
+
+// New builds the notifiers in the config.
+func New(notifierConfigs []config.NotifierConfig) ([]Notifier, error) {
+	var notifiers []Notifier
+	seen := map[string]bool{}
+
+	for i, notifierConfig := range notifierConfigs {
+		if notifierConfig.Name == "" {
+			return nil, fmt.Errorf("notifier %d needs a name", i+1)
+		}
+		if seen[notifierConfig.Name] {
+			return nil, fmt.Errorf("there's more than one notifier named %q", notifierConfig.Name)
+		}
+		seen[notifierConfig.Name] = true
+
+		notifier, err := newNotifier(notifierConfig)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't set up notifier %q: %w", notifierConfig.Name, err)
+		}
+		notifiers = append(notifiers, notifier)
+	}
+
+	return notifiers, nil
+}
+
+func newNotifier(notifierConfig config.NotifierConfig) (Notifier, error) {
+	switch notifierConfig.Type {
+	case TypeCommand:
+		return NewCommandNotifier(notifierConfig)
+	case TypeSlack, TypeWebhook:
+		return NewWebhookNotifier(notifierConfig)
+	default:
+		return nil, fmt.Errorf("unknown type %q; expected %s, %s or %s", notifierConfig.Type, TypeCommand, TypeSlack, TypeWebhook)
+	}
+}
+
+// Dispatch sends every event to every notifier, skipping events the ledger says a notifier
+// already announced. It keeps going when a notifier fails and returns the first error.
+func Dispatch(notifiers []Notifier, events []Event, ledger *Ledger) error {
+	var firstErr error
+
+	for _, notifier := range notifiers {
+		for _, event := range events {
+			if !notifier.Wants(event.Kind) {
+				continue
+			}
+			if ledger.Has(notifier.Name(), event) {
+				// Keep remembering it for as long as it's happening, e.g. while the MR is in the queue.
+				ledger.Add(notifier.Name(), event)
+				continue
+			}
+
+			if err := notifier.Notify(event); err != nil {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("%s couldn't announce %s: %w", notifier.Name(), event.MergeRequest.WebURL, err)
+				}
+				continue
+			}
+
+			ledger.Add(notifier.Name(), event)
+		}
+	}
+
+	return firstErr
+}
+
+// parseTemplate parses a notifier's template with the --format helpers plus `json`,
+// which quotes a value for use in a JSON body.
+func parseTemplate(name string, text string) (*template.Template, error) {
+	funcs := template.FuncMap{
+		"json": func(value interface{}) (string, error) {
+			data, err := marshalJson(value)
+			return string(data), err
+		},
+		"record": mrs.NewMergeRequestRecord,
+	}
+	for funcName, fn := range mrs.TemplateFuncs {
+		funcs[funcName] = fn
+	}
+
+	tmpl, err := template.New(name).Funcs(funcs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't parse template: %w", err)
+	}
+
+	return tmpl, nil
+}
@@ -0,0 +1,346 @@
+package notify
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mjburtenshaw/macglab/config"
+	"github.com/xanzy/go-gitlab"
+)
+
+// webhookServer is a local stand-in for a chat webhook. It fails the first failures requests.
+type webhookServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	failures int
+	bodies   []string
+	headers  []http.Header
+}
+
+func newWebhookServer(t *testing.T, failures int) *webhookServer {
+	t.Helper()
+
+	server := &webhookServer{failures: failures}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		server.mu.Lock()
+		defer server.mu.Unlock()
+
+		if server.failures > 0 {
+			server.failures--
+			http.Error(w, "try again", http.StatusServiceUnavailable)
+			return
+		}
+		server.bodies = append(server.bodies, string(body))
+		server.headers = append(server.headers, r.Header.Clone())
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func (server *webhookServer) received() []string {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	return append([]string(nil), server.bodies...)
+}
+
+func noRetryDelays(t *testing.T) {
+	t.Helper()
+	original := retryDelays
+	retryDelays = []time.Duration{0, 0, 0}
+	t.Cleanup(func() { retryDelays = original })
+}
+
+func testEvent() Event {
+	return Event{
+		Kind: EventJoinedQueue,
+		MergeRequest: &gitlab.MergeRequest{
+			IID:    3,
+			Title:  `Fix "quotes"`,
+			Author: &gitlab.BasicUser{Username: "alice"},
+			WebURL: "https://gitlab.example.com/acme/web/-/merge_requests/3",
+		},
+	}
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   config.NotifierConfig
+		failures int
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:   "slack wraps the default message in text",
+			config: config.NotifierConfig{Type: TypeSlack},
+			want:   `{"text":"New MR for review: <https://gitlab.example.com/acme/web/-/merge_requests/3|Fix \"quotes\"> by @alice"}`,
+		},
+		{
+			name:   "slack uses a custom template",
+			config: config.NotifierConfig{Type: TypeSlack, Template: "{{.MergeRequest.Author.Username}} needs a review"},
+			want:   `{"text":"alice needs a review"}`,
+		},
+		{
+			name:   "webhook sends the event as JSON by default",
+			config: config.NotifierConfig{Type: TypeWebhook},
+			want:   `{"event":"joined_queue","summary":"New MR for review","merge_request":{"schema_version":1,"project_path":"acme/web","iid":3,"title":"Fix \"quotes\"","author":{"id":0,"username":"alice","name":""},"reviewers":[],"labels":[],"draft":false,"detailed_merge_status":"","created_at":null,"updated_at":null,"web_url":"https://gitlab.example.com/acme/web/-/merge_requests/3"}}`,
+		},
+		{
+			name:   "webhook renders a custom body",
+			config: config.NotifierConfig{Type: TypeWebhook, Template: `{"title": {{json .MergeRequest.Title}}, "iid": {{.MergeRequest.IID}}}`},
+			want:   `{"title": "Fix \"quotes\"", "iid": 3}`,
+		},
+		{
+			name:     "retries server errors",
+			config:   config.NotifierConfig{Type: TypeSlack, Template: "hi"},
+			failures: 2,
+			want:     `{"text":"hi"}`,
+		},
+		{
+			name:     "gives up after the last retry",
+			config:   config.NotifierConfig{Type: TypeSlack, Template: "hi"},
+			failures: 4,
+			wantErr:  true,
+		},
+		{
+			name:    "rejects templates that don't render JSON",
+			config:  config.NotifierConfig{Type: TypeWebhook, Template: "not json"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			noRetryDelays(t)
+			server := newWebhookServer(t, tt.failures)
+
+			tt.config.Name = "team"
+			tt.config.Url = server.URL
+			notifier, err := NewWebhookNotifier(tt.config)
+			if err != nil {
+				t.Fatalf("NewWebhookNotifier() error = %v", err)
+			}
+
+			err = notifier.Notify(testEvent())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Notify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			bodies := server.received()
+			if len(bodies) != 1 {
+				t.Fatalf("webhook received %d requests, want 1", len(bodies))
+			}
+			if bodies[0] != tt.want {
+				t.Errorf("body = %s, want %s", bodies[0], tt.want)
+			}
+		})
+	}
+}
+
+func TestWebhookNotifierHeaders(t *testing.T) {
+	server := newWebhookServer(t, 0)
+
+	notifier, err := NewWebhookNotifier(config.NotifierConfig{
+		Name:    "team",
+		Type:    TypeWebhook,
+		Url:     server.URL,
+		Headers: map[string]string{"Authorization": "Bearer secret"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := notifier.Notify(testEvent()); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := server.headers[0].Get("Authorization"); got != "Bearer secret" {
+		t.Errorf("Authorization = %q, want %q", got, "Bearer secret")
+	}
+	if got := server.headers[0].Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got)
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		configs []config.NotifierConfig
+		wantErr bool
+	}{
+		{name: "no notifiers"},
+		{name: "slack", configs: []config.NotifierConfig{{Name: "a", Type: TypeSlack, Url: "https://example.com"}}},
+		{name: "missing name", configs: []config.NotifierConfig{{Type: TypeSlack, Url: "https://example.com"}}, wantErr: true},
+		{name: "missing url", configs: []config.NotifierConfig{{Name: "a", Type: TypeWebhook}}, wantErr: true},
+		{name: "placeholder url", configs: []config.NotifierConfig{{Name: "a", Type: TypeSlack, Url: "<your_webhook_url_here>"}}, wantErr: true},
+		{name: "unknown event", configs: []config.NotifierConfig{{Name: "a", Type: TypeSlack, Url: "https://example.com", Events: []string{"merged"}}}, wantErr: true},
+		{name: "command that isn't installed", configs: []config.NotifierConfig{{Name: "a", Type: TypeCommand, Command: "macglab-no-such-command"}}},
+		{name: "unknown type", configs: []config.NotifierConfig{{Name: "a", Type: "pager", Url: "https://example.com"}}, wantErr: true},
+		{name: "bad template", configs: []config.NotifierConfig{{Name: "a", Type: TypeSlack, Url: "https://example.com", Template: "{{"}}, wantErr: true},
+		{
+			name: "duplicate names",
+			configs: []config.NotifierConfig{
+				{Name: "a", Type: TypeSlack, Url: "https://example.com"},
+				{Name: "a", Type: TypeWebhook, Url: "https://example.com"},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.configs); (err != nil) != tt.wantErr {
+				t.Errorf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDispatchAnnouncesOnce(t *testing.T) {
+	noRetryDelays(t)
+	server := newWebhookServer(t, 0)
+	ledgerUrl := filepath.Join(t.TempDir(), "notified.json")
+
+	notifiers, err := New([]config.NotifierConfig{{Name: "team", Type: TypeSlack, Url: server.URL, Template: "{{.MergeRequest.IID}}"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other := testEvent()
+	other.MergeRequest = &gitlab.MergeRequest{IID: 4, WebURL: "https://gitlab.example.com/acme/web/-/merge_requests/4"}
+
+	// Each run loads the ledger the previous one saved, like separate invocations of notify.
+	for _, events := range [][]Event{{testEvent()}, {testEvent(), other}} {
+		ledger, err := LoadLedger(ledgerUrl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := Dispatch(notifiers, events, ledger); err != nil {
+			t.Fatal(err)
+		}
+		if err := ledger.Save(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := server.received()
+	want := []string{`{"text":"3"}`, `{"text":"4"}`}
+	gotJson, _ := json.Marshal(got)
+	wantJson, _ := json.Marshal(want)
+	if string(gotJson) != string(wantJson) {
+		t.Errorf("webhook received %s, want %s", gotJson, wantJson)
+	}
+}
+
+func TestDispatchRemembersEventsStillHappening(t *testing.T) {
+	server := newWebhookServer(t, 0)
+	ledgerUrl := filepath.Join(t.TempDir(), "notified.json")
+
+	notifiers, err := New([]config.NotifierConfig{{Name: "team", Type: TypeSlack, Url: server.URL, Template: "hi"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The MR was announced long ago and is still in the queue.
+	ledger, err := LoadLedger(ledgerUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ledger.entries[ledgerKey("team", testEvent())] = time.Now().Add(-2 * ledgerRetention)
+
+	if err := Dispatch(notifiers, []Event{testEvent()}, ledger); err != nil {
+		t.Fatal(err)
+	}
+	if err := ledger.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := LoadLedger(ledgerUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reloaded.Has("team", testEvent()) {
+		t.Error("ledger forgot an announcement whose MR is still in the queue")
+	}
+	if len(server.received()) != 0 {
+		t.Errorf("webhook received %v, want the MR not announced again", server.received())
+	}
+}
+
+func TestDispatchRetriesFailedAnnouncementsNextTime(t *testing.T) {
+	noRetryDelays(t)
+	server := newWebhookServer(t, 4)
+
+	notifiers, err := New([]config.NotifierConfig{{Name: "team", Type: TypeSlack, Url: server.URL, Template: "hi"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ledger, err := LoadLedger(filepath.Join(t.TempDir(), "notified.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Dispatch(notifiers, []Event{testEvent()}, ledger); err == nil {
+		t.Fatal("Dispatch() error = nil, want an error")
+	}
+	if ledger.Has("team", testEvent()) {
+		t.Fatal("ledger recorded a failed announcement")
+	}
+
+	if err := Dispatch(notifiers, []Event{testEvent()}, ledger); err != nil {
+		t.Fatalf("Dispatch() error = %v", err)
+	}
+	if len(server.received()) != 1 {
+		t.Errorf("webhook received %d announcements, want 1", len(server.received()))
+	}
+}
+
+func TestDispatchFiltersEvents(t *testing.T) {
+	server := newWebhookServer(t, 0)
+	ledger, err := LoadLedger(filepath.Join(t.TempDir(), "notified.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notifiers, err := New([]config.NotifierConfig{
+		{Name: "default", Type: TypeSlack, Url: server.URL, Template: "default {{.Kind}}"},
+		{Name: "mine", Type: TypeSlack, Url: server.URL, Template: "mine {{.Kind}} {{.MergeRequest.SHA}}", Events: []string{EventMergeable}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mr := testEvent().MergeRequest
+	pushed := *mr
+	pushed.SHA = "bbb"
+	mr.SHA = "aaa"
+
+	events := []Event{
+		{Kind: EventJoinedQueue, MergeRequest: mr},
+		{Kind: EventMergeable, MergeRequest: mr},
+		{Kind: EventMergeable, MergeRequest: mr},
+		// The same MR becomes mergeable again after new commits.
+		{Kind: EventMergeable, MergeRequest: &pushed},
+	}
+	if err := Dispatch(notifiers, events, ledger); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{`{"text":"default joined_queue"}`, `{"text":"mine mergeable aaa"}`, `{"text":"mine mergeable bbb"}`}
+	if got := server.received(); !reflect.DeepEqual(got, want) {
+		t.Errorf("webhook received %v, want %v", got, want)
+	}
+}
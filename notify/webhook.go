@@ -0,0 +1,181 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/mjburtenshaw/macglab/config"
+	"github.com/mjburtenshaw/macglab/mrs"
+)
+
+// DefaultSlackTemplate is the message a slack notifier sends when it has no template.
+const DefaultSlackTemplate = "{{.Summary}}: <{{.MergeRequest.WebURL}}|{{.MergeRequest.Title}}> by @{{.MergeRequest.Author.Username}}"
+
+// retryDelays are how long to wait before each retry of a failed webhook. Swapped out in tests.
+var retryDelays = []time.Duration{time.Second, 2 * time.Second, 4 * time.Second}
+
+// WebhookNotifier posts events as JSON to a URL. Slack-compatible notifiers wrap the
+// rendered template in `{"text": ...}`; generic ones send the rendered template as the body.
+type WebhookNotifier struct {
+	eventFilter
+	name     string
+	kind     string
+	url      string
+	headers  map[string]string
+	template *template.Template
+	client   *http.Client
+}
+
+// webhookBody is what a generic webhook sends when it has no template.
+type webhookBody struct {
+	Event        string                 `json:"event"`
+	Summary      string                 `json:"summary"`
+	MergeRequest mrs.MergeRequestRecord `json:"merge_request"`
+}
+
+// NewWebhookNotifier builds a slack or generic webhook notifier.
+func NewWebhookNotifier(notifierConfig config.NotifierConfig) (*WebhookNotifier, error) {
+	if notifierConfig.Url == "" {
+		return nil, fmt.Errorf("a %s notifier needs a url", notifierConfig.Type)
+	}
+	if parsedUrl, err := url.Parse(notifierConfig.Url); err != nil || (parsedUrl.Scheme != "http" && parsedUrl.Scheme != "https") || parsedUrl.Host == "" {
+		return nil, fmt.Errorf("a %s notifier's url must be an http or https URL", notifierConfig.Type)
+	}
+
+	filter, err := newEventFilter(notifierConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	notifier := &WebhookNotifier{
+		eventFilter: filter,
+		name:        notifierConfig.Name,
+		kind:        notifierConfig.Type,
+		url:         notifierConfig.Url,
+		headers:     notifierConfig.Headers,
+		client:      &http.Client{Timeout: 10 * time.Second},
+	}
+
+	templateText := notifierConfig.Template
+	if templateText == "" && notifierConfig.Type == TypeSlack {
+		templateText = DefaultSlackTemplate
+	}
+	if templateText != "" {
+		tmpl, err := parseTemplate(notifierConfig.Name, templateText)
+		if err != nil {
+			return nil, err
+		}
+		notifier.template = tmpl
+	}
+
+	return notifier, nil
+}
+
+// Name implements Notifier.
+func (notifier *WebhookNotifier) Name() string {
+	return notifier.name
+}
+
+// Notify implements Notifier. Network errors, 429s and 5xxs are retried.
+func (notifier *WebhookNotifier) Notify(event Event) error {
+	body, err := notifier.body(event)
+	if err != nil {
+		return err
+	}
+
+	err = notifier.post(body)
+	for _, delay := range retryDelays {
+		if err == nil || !isRetryable(err) {
+			break
+		}
+		time.Sleep(delay)
+		err = notifier.post(body)
+	}
+
+	return err
+}
+
+func (notifier *WebhookNotifier) body(event Event) ([]byte, error) {
+	if notifier.template == nil {
+		return marshalJson(webhookBody{
+			Event:        event.Kind,
+			Summary:      event.Summary(),
+			MergeRequest: mrs.NewMergeRequestRecord(event.MergeRequest),
+		})
+	}
+
+	var rendered bytes.Buffer
+	if err := notifier.template.Execute(&rendered, event); err != nil {
+		return nil, fmt.Errorf("couldn't execute template: %w", err)
+	}
+
+	if notifier.kind == TypeSlack {
+		return marshalJson(map[string]string{"text": rendered.String()})
+	}
+
+	if !json.Valid(rendered.Bytes()) {
+		return nil, fmt.Errorf("template didn't render valid JSON: %s", rendered.String())
+	}
+	return rendered.Bytes(), nil
+}
+
+// marshalJson is json.Marshal without escaping <, > and &, which Slack uses for links.
+func marshalJson(value interface{}) ([]byte, error) {
+	var data bytes.Buffer
+	encoder := json.NewEncoder(&data)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(data.Bytes(), []byte("\n")), nil
+}
+
+// statusError is a webhook response that wasn't a 2xx.
+type statusError struct {
+	statusCode int
+	body       string
+}
+
+func (err *statusError) Error() string {
+	return fmt.Sprintf("webhook responded %d: %s", err.statusCode, err.body)
+}
+
+func isRetryable(err error) bool {
+	statusErr, ok := err.(*statusError)
+	if !ok {
+		// Network errors are worth another try.
+		return true
+	}
+	return statusErr.statusCode == http.StatusTooManyRequests || statusErr.statusCode >= 500
+}
+
+func (notifier *WebhookNotifier) post(body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, notifier.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("couldn't build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range notifier.headers {
+		req.Header.Set(name, value)
+	}
+
+	res, err := notifier.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		responseBody, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return &statusError{statusCode: res.StatusCode, body: strings.TrimSpace(string(responseBody))}
+	}
+
+	return nil
+}
@@ -0,0 +1,158 @@
+// Package snooze keeps track of merge requests hidden from the queue, until a time, until their
+// next push, or for good.
+package snooze
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// Entry is why and until when a merge request is hidden.
+type Entry struct {
+	// Reference is how the merge request was given, e.g. `group/project!123`, for listing snoozes.
+	Reference string `json:"reference"`
+	// Sha is the merge request's head commit when it was snoozed. New commits unsnooze it.
+	Sha string `json:"sha"`
+	// Until is when the snooze ends. Nil means it only ends with new commits.
+	Until *time.Time `json:"until,omitempty"`
+	// Ignored hides the merge request for good, even after new commits.
+	Ignored bool `json:"ignored,omitempty"`
+	// SnoozedAt is when the merge request was snoozed or ignored.
+	SnoozedAt time.Time `json:"snoozed_at"`
+}
+
+// Describe says how long the entry hides its merge request, e.g. `until next push`.
+func (entry Entry) Describe() string {
+	switch {
+	case entry.Ignored:
+		return "ignored"
+	case entry.Until != nil:
+		return fmt.Sprintf("until %s or next push", entry.Until.Local().Format("Mon Jan 2 15:04"))
+	default:
+		return "until next push"
+	}
+}
+
+// List is the snoozed and ignored merge requests, keyed by web URL.
+type List struct {
+	url     string
+	entries map[string]Entry
+}
+
+// Load reads the list at listUrl. A missing file is an empty list.
+func Load(listUrl string) (*List, error) {
+	list := &List{url: listUrl, entries: map[string]Entry{}}
+
+	data, err := os.ReadFile(listUrl)
+	if os.IsNotExist(err) {
+		return list, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read %s: %w", listUrl, err)
+	}
+
+	if err := json.Unmarshal(data, &list.entries); err != nil {
+		return nil, fmt.Errorf("couldn't unmarshal %s: %w", listUrl, err)
+	}
+
+	return list, nil
+}
+
+// Snooze hides a merge request until its next push, or until a time if until isn't nil.
+func (list *List) Snooze(mr *gitlab.MergeRequest, reference string, until *time.Time, now time.Time) Entry {
+	entry := Entry{Reference: reference, Sha: mr.SHA, Until: until, SnoozedAt: now}
+	list.entries[mr.WebURL] = entry
+	return entry
+}
+
+// Ignore hides a merge request for good.
+func (list *List) Ignore(mr *gitlab.MergeRequest, reference string, now time.Time) Entry {
+	entry := Entry{Reference: reference, Sha: mr.SHA, Ignored: true, SnoozedAt: now}
+	list.entries[mr.WebURL] = entry
+	return entry
+}
+
+// Unsnooze shows a snoozed or ignored merge request again. It reports whether it was hidden.
+func (list *List) Unsnooze(mr *gitlab.MergeRequest) bool {
+	_, ok := list.entries[mr.WebURL]
+	delete(list.entries, mr.WebURL)
+	return ok
+}
+
+// Entries returns the snoozed and ignored merge requests, most recently snoozed first.
+func (list *List) Entries() []Entry {
+	entries := make([]Entry, 0, len(list.entries))
+	for _, entry := range list.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].SnoozedAt.After(entries[j].SnoozedAt) })
+	return entries
+}
+
+// Filter splits merge requests into those to show and those snoozed or ignored. Snoozes that
+// have ended, by time or by new commits, are removed from the list; changed reports whether
+// any were, so the caller knows to save it.
+func (list *List) Filter(mrs []*gitlab.MergeRequest, now time.Time) (visible []*gitlab.MergeRequest, hidden []*gitlab.MergeRequest, changed bool) {
+	visible = []*gitlab.MergeRequest{}
+	hidden = []*gitlab.MergeRequest{}
+
+	for _, mr := range mrs {
+		entry, ok := list.entries[mr.WebURL]
+		if !ok {
+			visible = append(visible, mr)
+			continue
+		}
+
+		if entry.Ignored {
+			hidden = append(hidden, mr)
+			continue
+		}
+
+		pushed := entry.Sha != "" && mr.SHA != "" && mr.SHA != entry.Sha
+		expired := entry.Until != nil && !now.Before(*entry.Until)
+		if pushed || expired {
+			delete(list.entries, mr.WebURL)
+			changed = true
+			visible = append(visible, mr)
+			continue
+		}
+
+		hidden = append(hidden, mr)
+	}
+
+	return visible, hidden, changed
+}
+
+// Save writes the list.
+func (list *List) Save() error {
+	data, err := json.MarshalIndent(list.entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("couldn't marshal snoozes: %w", err)
+	}
+
+	tempFile, err := os.CreateTemp(filepath.Dir(list.url), filepath.Base(list.url)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("couldn't save %s: %w", list.url, err)
+	}
+	defer os.Remove(tempFile.Name())
+
+	if _, err := tempFile.Write(data); err != nil {
+		tempFile.Close()
+		return fmt.Errorf("couldn't save %s: %w", list.url, err)
+	}
+	if err := tempFile.Close(); err != nil {
+		return fmt.Errorf("couldn't save %s: %w", list.url, err)
+	}
+
+	if err := os.Rename(tempFile.Name(), list.url); err != nil {
+		return fmt.Errorf("couldn't save %s: %w", list.url, err)
+	}
+
+	return nil
+}
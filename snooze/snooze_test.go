@@ -0,0 +1,124 @@
+package snooze
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+func testMergeRequest(id int, sha string) *gitlab.MergeRequest {
+	return &gitlab.MergeRequest{
+		ID:     id,
+		IID:    id,
+		SHA:    sha,
+		WebURL: fmt.Sprintf("https://gitlab.example.com/acme/web/-/merge_requests/%d", id),
+	}
+}
+
+func ids(mrs []*gitlab.MergeRequest) []int {
+	ids := []int{}
+	for _, mr := range mrs {
+		ids = append(ids, mr.ID)
+	}
+	return ids
+}
+
+func TestFilter(t *testing.T) {
+	now := time.Date(2023, 9, 12, 12, 0, 0, 0, time.UTC)
+	tomorrow := now.Add(24 * time.Hour)
+	yesterday := now.Add(-24 * time.Hour)
+
+	list, err := Load(filepath.Join(t.TempDir(), "snoozed.json"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	list.Snooze(testMergeRequest(1, "aaa"), "acme/web!1", nil, yesterday)
+	list.Snooze(testMergeRequest(2, "bbb"), "acme/web!2", &tomorrow, yesterday)
+	list.Snooze(testMergeRequest(3, "ccc"), "acme/web!3", &now, yesterday)
+	list.Ignore(testMergeRequest(4, "ddd"), "acme/web!4", yesterday)
+
+	queue := []*gitlab.MergeRequest{
+		testMergeRequest(1, "aaa"), // still snoozed until its next push
+		testMergeRequest(2, "b2b"), // pushed to, so unsnoozed
+		testMergeRequest(3, "ccc"), // snoozed until now, so unsnoozed
+		testMergeRequest(4, "d2d"), // ignored, even after a push
+		testMergeRequest(5, "eee"), // never snoozed
+	}
+
+	visible, hidden, changed := list.Filter(queue, now)
+	if want := []int{2, 3, 5}; !reflect.DeepEqual(ids(visible), want) {
+		t.Errorf("visible = %v, want %v", ids(visible), want)
+	}
+	if want := []int{1, 4}; !reflect.DeepEqual(ids(hidden), want) {
+		t.Errorf("hidden = %v, want %v", ids(hidden), want)
+	}
+	if !changed {
+		t.Errorf("changed = false, want true after snoozes ended")
+	}
+
+	var references []string
+	for _, entry := range list.Entries() {
+		references = append(references, entry.Reference)
+	}
+	if len(references) != 2 {
+		t.Errorf("entries = %v, want the snooze of !1 and the ignore of !4", references)
+	}
+
+	if _, _, changed := list.Filter(queue, now); changed {
+		t.Errorf("changed = true on a second filter, want false")
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	listUrl := filepath.Join(t.TempDir(), "snoozed.json")
+	now := time.Date(2023, 9, 12, 12, 0, 0, 0, time.UTC)
+	until := now.Add(48 * time.Hour)
+
+	list, err := Load(listUrl)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	list.Snooze(testMergeRequest(1, "aaa"), "acme/web!1", &until, now)
+	list.Ignore(testMergeRequest(2, "bbb"), "acme/web!2", now.Add(time.Hour))
+	if err := list.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := Load(listUrl)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !reflect.DeepEqual(loaded.Entries(), list.Entries()) {
+		t.Errorf("loaded entries = %+v, want %+v", loaded.Entries(), list.Entries())
+	}
+
+	if !loaded.Unsnooze(testMergeRequest(2, "bbb")) {
+		t.Errorf("Unsnooze() = false for an ignored merge request, want true")
+	}
+	if loaded.Unsnooze(testMergeRequest(2, "bbb")) {
+		t.Errorf("Unsnooze() = true twice, want false")
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	until := time.Date(2023, 9, 14, 12, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		entry Entry
+		want  string
+	}{
+		{Entry{}, "until next push"},
+		{Entry{Until: &until}, "until Thu Sep 14 12:00 or next push"},
+		{Entry{Ignored: true}, "ignored"},
+	}
+	for _, test := range tests {
+		if got := test.entry.Describe(); got != test.want {
+			t.Errorf("Describe() = %q, want %q", got, test.want)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+// Package users remembers the IDs of GitLab usernames, so a username in the config or a flag
+// only costs a request the first time it's used.
+package users
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Cache maps usernames to user IDs for each GitLab instance, keyed by its API URL. GitLab usernames
+// aren't case-sensitive, so neither is the cache.
+type Cache struct {
+	url       string
+	instances map[string]map[string]int
+	changed   bool
+}
+
+// Load reads the cache at cacheUrl. A missing file is an empty cache.
+func Load(cacheUrl string) (*Cache, error) {
+	cache := &Cache{url: cacheUrl, instances: map[string]map[string]int{}}
+
+	data, err := os.ReadFile(cacheUrl)
+	if os.IsNotExist(err) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read %s: %w", cacheUrl, err)
+	}
+
+	if err := json.Unmarshal(data, &cache.instances); err != nil {
+		return nil, fmt.Errorf("couldn't unmarshal %s: %w", cacheUrl, err)
+	}
+
+	return cache, nil
+}
+
+// Id returns the ID of a username on an instance, if it's known.
+func (cache *Cache) Id(instance string, username string) (int, bool) {
+	id, ok := cache.instances[instance][strings.ToLower(username)]
+	return id, ok
+}
+
+// Add remembers a username's ID on an instance.
+func (cache *Cache) Add(instance string, username string, id int) {
+	if current, ok := cache.Id(instance, username); ok && current == id {
+		return
+	}
+	if cache.instances[instance] == nil {
+		cache.instances[instance] = map[string]int{}
+	}
+	cache.instances[instance][strings.ToLower(username)] = id
+	cache.changed = true
+}
+
+// Save writes the cache if anything was added since it was loaded.
+func (cache *Cache) Save() error {
+	if !cache.changed {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(cache.instances, "", "  ")
+	if err != nil {
+		return fmt.Errorf("couldn't marshal users: %w", err)
+	}
+
+	tempFile, err := os.CreateTemp(filepath.Dir(cache.url), filepath.Base(cache.url)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("couldn't save %s: %w", cache.url, err)
+	}
+	defer os.Remove(tempFile.Name())
+
+	if _, err := tempFile.Write(data); err != nil {
+		tempFile.Close()
+		return fmt.Errorf("couldn't save %s: %w", cache.url, err)
+	}
+	if err := tempFile.Close(); err != nil {
+		return fmt.Errorf("couldn't save %s: %w", cache.url, err)
+	}
+
+	if err := os.Rename(tempFile.Name(), cache.url); err != nil {
+		return fmt.Errorf("couldn't save %s: %w", cache.url, err)
+	}
+
+	cache.changed = false
+	return nil
+}
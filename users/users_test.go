@@ -0,0 +1,45 @@
+package users
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCache(t *testing.T) {
+	cacheUrl := filepath.Join(t.TempDir(), "users.json")
+	cache, err := Load(cacheUrl)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if err := cache.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if _, err := os.Stat(cacheUrl); !os.IsNotExist(err) {
+		t.Errorf("Save() wrote an unchanged cache")
+	}
+
+	cache.Add("https://gitlab.com/api/v4/", "alice", 7)
+	cache.Add("https://gitlab.example.com/api/v4/", "alice", 12)
+	if err := cache.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	reloaded, err := Load(cacheUrl)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if id, ok := reloaded.Id("https://gitlab.com/api/v4/", "alice"); !ok || id != 7 {
+		t.Errorf("Id() = %d, %v, want 7 on gitlab.com", id, ok)
+	}
+	if id, ok := reloaded.Id("https://gitlab.example.com/api/v4/", "alice"); !ok || id != 12 {
+		t.Errorf("Id() = %d, %v, want 12 on gitlab.example.com", id, ok)
+	}
+	if id, ok := reloaded.Id("https://gitlab.com/api/v4/", "Alice"); !ok || id != 7 {
+		t.Errorf("Id() = %d, %v, want 7 for Alice, whatever the case", id, ok)
+	}
+	if _, ok := reloaded.Id("https://gitlab.com/api/v4/", "bob"); ok {
+		t.Errorf("Id() found bob, who was never added")
+	}
+}
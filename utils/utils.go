@@ -4,13 +4,135 @@ import (
 	"bufio"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/term"
 )
 
+// stdin is shared by every prompt so input buffered by one isn't lost to the next.
+var stdin = bufio.NewReader(os.Stdin)
+
 func AskBinaryQuestion(question string) (response string) {
-	reader := bufio.NewReader(os.Stdin)
+	return AskQuestion(question)
+}
+
+// AskQuestion prints a question and returns the line typed in response, trimmed.
+func AskQuestion(question string) (response string) {
 	fmt.Print(question)
-	response, _ = reader.ReadString('\n')
+	response, _ = stdin.ReadString('\n')
 	response = strings.TrimSpace(response)
 	return response
 }
+
+// AskSecret prints a question and returns the line typed in response, trimmed, without echoing it on a terminal.
+func AskSecret(question string) (response string) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return AskQuestion(question)
+	}
+
+	fmt.Print(question)
+	secret, _ := term.ReadPassword(fd)
+	fmt.Println()
+	return strings.TrimSpace(string(secret))
+}
+
+// Choice is an answer to AskChoice, picked by pressing Key.
+type Choice struct {
+	Key   rune
+	Label string
+}
+
+// String shows the choice with its key in brackets, e.g. `[o]pen`, or `open [z]` if the label lacks the key.
+func (choice Choice) String() string {
+	if i := strings.IndexRune(choice.Label, choice.Key); i >= 0 {
+		keyEnd := i + len(string(choice.Key))
+		return choice.Label[:i] + "[" + string(choice.Key) + "]" + choice.Label[keyEnd:]
+	}
+	return fmt.Sprintf("%s [%c]", choice.Label, choice.Key)
+}
+
+// ctrlC is what reading a raw terminal returns when Ctrl+C is pressed.
+const ctrlC = 3
+
+// AskChoice prints a question and its choices, e.g. `Next? [o]pen [q]uit: `, and returns the key
+// of the choice picked. On a terminal a single key press answers; otherwise the first character
+// of a line does. It asks again until a listed key is picked. Ctrl+C and end of input pick quit,
+// the key of the last choice.
+func AskChoice(question string, choices []Choice) rune {
+	labels := make([]string, len(choices))
+	for i, choice := range choices {
+		labels[i] = choice.String()
+	}
+	prompt := fmt.Sprintf("%s %s: ", question, strings.Join(labels, " "))
+	quit := choices[len(choices)-1].Key
+
+	for {
+		fmt.Print(prompt)
+
+		key, ok := readKey(stdin)
+		if !ok {
+			fmt.Println()
+			return quit
+		}
+
+		for _, choice := range choices {
+			if key == choice.Key {
+				return key
+			}
+		}
+	}
+}
+
+// readKey reads a single key press from a terminal, or the first character of a line otherwise.
+func readKey(reader *bufio.Reader) (rune, bool) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		line, err := reader.ReadString('\n')
+		line = strings.TrimSpace(line)
+		if line == "" {
+			return 0, err == nil
+		}
+		return []rune(strings.ToLower(line))[0], true
+	}
+
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return 0, false
+	}
+	key, _, err := reader.ReadRune()
+	term.Restore(fd, state)
+
+	if err != nil || key == ctrlC {
+		return 0, false
+	}
+	fmt.Println(string(key))
+	return unicode.ToLower(key), true
+}
+
+// TerminalWidth returns the width of the terminal stdout is attached to, or 0 if it isn't a terminal.
+func TerminalWidth() int {
+	fd := int(os.Stdout.Fd())
+	if !term.IsTerminal(fd) {
+		return 0
+	}
+	width, _, err := term.GetSize(fd)
+	if err != nil {
+		return 0
+	}
+	return width
+}
+
+// ParseDuration parses a duration like time.ParseDuration, plus whole days and weeks, e.g. `2d` or `1w`.
+func ParseDuration(s string) (time.Duration, error) {
+	units := map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour}
+	for suffix, unit := range units {
+		if count, err := strconv.Atoi(strings.TrimSuffix(s, suffix)); strings.HasSuffix(s, suffix) && err == nil {
+			return time.Duration(count) * unit, nil
+		}
+	}
+	return time.ParseDuration(s)
+}